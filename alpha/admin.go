@@ -0,0 +1,33 @@
+package main
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/gin-gonic/gin"
+)
+
+// dashboard is a self-contained page rendering hystrix stream, no external assets so it works offline
+//
+//go:embed dashboard.html
+var dashboard []byte
+
+// initAdminHandler mounts circuits visibility routes,
+// returned stream handler has to be stopped on shutdown
+func initAdminHandler(r *gin.Engine) *hystrix.StreamHandler {
+	streamHandler := hystrix.NewStreamHandler()
+	streamHandler.Start()
+
+	admin := r.Group("/admin")
+
+	// hystrix metrics stream, server-sent events published every second
+	admin.GET("/hystrix.stream", gin.WrapH(streamHandler))
+
+	// circuit dashboard consuming the stream above
+	admin.GET("/dashboard", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", dashboard)
+	})
+
+	return streamHandler
+}
@@ -4,37 +4,60 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
+	"os"
 	"time"
 
 	httpclient_x "playground/common/httpclient"
+	"playground/common/httpclient/promcollector"
+	"playground/common/tracing"
 
 	"github.com/afex/hystrix-go/hystrix"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
+// logger is shared by handlers and httpclient
+var logger = httpclient_x.NewStdLogger(os.Stdout, httpclient_x.LevelInfo)
+
 func main() {
 
 	// init gin
 	r := gin.Default()
 
+	// tracing, continuing inbound traces and propagating them to zulu.
+	// spans are dropped until an exporter is plugged, e.g. sdktrace.WithBatcher(exporter)
+	tracer := tracing.NewTracer(tracing.Config{TracerProvider: sdktrace.NewTracerProvider()})
+	r.Use(tracer.GinMiddleware())
+
 	// hystrix configs
 	hystrix.ConfigureCommand("something", hystrix.CommandConfig{
 		SleepWindow:            10000, // in ms
 		RequestVolumeThreshold: 5,
 	})
 
+	// httpclient metrics, exposed at /metrics
+	collector, err := promcollector.NewCollector(prometheus.DefaultRegisterer, promcollector.Options{Namespace: "alpha"})
+	if err != nil {
+		panic(err)
+	}
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
 	// init handler
-	initHandler(r)
+	initHandler(r, collector, tracer)
+
+	// init admin handler, circuits dashboard is served at /admin/dashboard
+	streamHandler := initAdminHandler(r)
+	defer streamHandler.Stop()
 
 	// run
 	r.Run("localhost:3000")
 }
 
-func initHandler(r *gin.Engine) {
+func initHandler(r *gin.Engine, metricsCollector httpclient_x.MetricsCollector, tracer *tracing.Tracer) {
 
 	// handler without circuit breaker
 	r.GET("/ping-a", func(c *gin.Context) {
@@ -44,7 +67,7 @@ func initHandler(r *gin.Engine) {
 		if err != nil {
 			c.JSON(500, gin.H{
 				"message": "service not available",
-				"error":   err,
+				"error":   err.Error(),
 			})
 			return
 		}
@@ -70,8 +93,7 @@ func initHandler(r *gin.Engine) {
 		}, func(e error) error {
 
 			//circuit breaker fallback function
-			fmt.Println("hystrix fallback")
-			fmt.Println(fmt.Sprintf("message: %v", e))
+			logger.Warn("hystrix fallback", "error", e)
 			return errors.New("hystrix fallback")
 		})
 		//circuit breaker ends
@@ -79,7 +101,7 @@ func initHandler(r *gin.Engine) {
 		if err != nil {
 			c.JSON(500, gin.H{
 				"message": "service not available",
-				"error":   err,
+				"error":   err.Error(),
 			})
 			return
 		}
@@ -94,23 +116,33 @@ func initHandler(r *gin.Engine) {
 
 		//initialization
 		client_x := httpclient_x.NewHttpClient(httpclient_x.Config{
-			Host:                  "http://localhost:3002",
-			Timeout:               10 * time.Second,
-			RetryCount:            5,
-			IsUsingCircuitBreaker: true,
+			Name:       "zulu",
+			Host:       "http://localhost:3002",
+			Timeout:    10 * time.Second,
+			RetryCount: 5,
+			// POST is only retried when carrying idempotency key
+			IdempotencyKeyGenerator: httpclient_x.NewIdempotencyKey,
+			IsUsingCircuitBreaker:   true,
 			CbConfig: httpclient_x.CircuitBreakerConfig{
-				SleepWindow:    10000,
-				ErrorThreshold: 10,
+				SleepWindow:            10000,
+				RequestVolumeThreshold: 10,
 				Fallback: func(c context.Context, e error) error {
 					somerandom("hi!")
 					return e
 				},
 			},
+			MetricsCollector:   metricsCollector,
+			CallMiddlewares:    []httpclient_x.Middleware{tracer.CallMiddleware},
+			AttemptMiddlewares: []httpclient_x.Middleware{tracer.AttemptMiddleware},
+			Logger:             logger,
+			RequestLog: &httpclient_x.RequestLogConfig{
+				RedactJSONFields: []string{"someInnerKeyValue1"},
+			},
 		})
 
 		//usage
-		// resp, errResp := client_x.Post("/ping", header, bytes.NewBuffer([]byte(jsonBody)))
-		resp, errResp := client_x.Do(http.MethodPost, httpclient_x.Parameter{
+		// response body is decoded into `response` and closed by the client
+		res, errResp := client_x.PostInto(c.Request.Context(), httpclient_x.Parameter{
 			Path:          "/ping",
 			PathVariables: []string{"path-variable-1"},
 			QueryParams: map[string]string{
@@ -126,54 +158,61 @@ func initHandler(r *gin.Engine) {
 				},
 				"someKeyValue2": "some-value-2",
 			},
-		})
+		}, &response)
 		if errResp != nil {
-			fmt.Printf("service: %s", errResp)
-			fmt.Println()
-		} else {
-			body, _ := ioutil.ReadAll(resp.Body)
-			fmt.Println("service: ", string(body))
-			json.Unmarshal(body, &response)
-			defer resp.Body.Close()
-		}
-
-		if errResp != nil {
-			c.JSON(500, gin.H{
+			logger.Error("calling zulu", "error", errResp)
+			c.JSON(httpStatusOf(errResp), gin.H{
 				"message": "service unavailable",
-				"error":   errResp,
+				"error":   errResp.Error(),
 			})
 			return
 		}
+		// breaking down where the time goes, e.g. dns, connect or zulu's think-time
+		timing, _ := httpclient_x.Timing(res)
+		logger.Info("calling zulu", "response", response, "ttfb", timing.TimeToFirstByte, "total", timing.Total)
 		c.JSON(200, response)
 	})
 }
 
-//dummy task
+// httpStatusOf maps httpclient's error into our response status
+func httpStatusOf(err error) int {
+	var httpErr *httpclient_x.HTTPError
+	switch {
+	case errors.Is(err, httpclient_x.ErrCircuitOpen), errors.Is(err, httpclient_x.ErrMaxConcurrency):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, httpclient_x.ErrTimeout):
+		return http.StatusGatewayTimeout
+	case errors.As(err, &httpErr):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+// dummy task
 func doTask() (map[string]string, error) {
-	fmt.Println("doing task-a")
-	fmt.Println("doing task-b")
+	logger.Info("doing task", "step", "a")
+	logger.Info("doing task", "step", "b")
 
 	var response map[string]string
 	resp, err1 := http.Get("http://localhost:3001/ping")
 	if err1 != nil {
-		fmt.Println("failing request")
+		logger.Error("failing request", "error", err1)
 		return response, errors.New("failing request")
 	}
 
 	body, err2 := io.ReadAll(resp.Body)
 	if err2 != nil {
-		fmt.Printf("error reading responses, message: %v", err1)
-		fmt.Println("failing parse")
+		logger.Error("failing parse", "error", err2)
 		return response, errors.New("failing parse")
 	}
 	json.Unmarshal(body, &response)
 
-	fmt.Println("doing task-c")
-	fmt.Println("doing task-d")
+	logger.Info("doing task", "step", "c")
+	logger.Info("doing task", "step", "d")
 
 	return response, nil
 }
 
 func somerandom(str string) {
-	fmt.Println("this some random fallback function, saying:", str)
+	logger.Info("this some random fallback function", "saying", str)
 }
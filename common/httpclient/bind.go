@@ -0,0 +1,367 @@
+package httpclient
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BindError is returned when Parameter.Bind cannot be bound into the request
+type BindError struct {
+	Field  string // struct field name
+	Reason string
+}
+
+// Error implements error
+func (e *BindError) Error() string {
+	return fmt.Sprintf("httpclient: failed binding field %s: %s", e.Field, e.Reason)
+}
+
+// binding tags of Parameter.Bind
+const (
+	bindTagPath       = "path"
+	bindTagQuery      = "query"
+	bindTagHeader     = "header"
+	bindTagJSON       = "json"
+	bindTagTimeFormat = "time_format"
+)
+
+// bindTag is the parsed form of binding tag, e.g. `query:"page,omitempty,required"`
+type bindTag struct {
+	name      string
+	omitEmpty bool
+	required  bool
+}
+
+// parseBindTag is a helper to parse binding tag
+func parseBindTag(tag string) bindTag {
+	parts := strings.Split(tag, ",")
+	t := bindTag{name: parts[0]}
+	for _, option := range parts[1:] {
+		switch option {
+		case "omitempty":
+			t.omitEmpty = true
+		case "required":
+			t.required = true
+		}
+	}
+	return t
+}
+
+// bindParameter is a helper to merge Parameter.Bind struct into the parameter,
+// the same way gin binds inbound requests:
+//
+//	type GetOrder struct {
+//		UserID  string    `path:"id"`
+//		OrderID int       `path:"orderId"`
+//		Status  []string  `query:"status,omitempty"`
+//		Since   time.Time `query:"since,omitempty" time_format:"2006-01-02"`
+//		TraceID string    `header:"x-trace-id,required"`
+//		Note    string    `json:"note,omitempty"`
+//		Due     time.Time `json:"due" time_format:"2006-01-02"`
+//	}
+//
+// path variables are always required, slices are sent as repeated query keys or headers,
+// json tagged fields become the body unless Parameter.Body is defined, time_format applies to them too
+func bindParameter(param Parameter) (Parameter, error) {
+	if param.Bind == nil {
+		return param, nil
+	}
+
+	v := reflect.ValueOf(param.Bind)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return param, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return param, fmt.Errorf("httpclient: Parameter.Bind must be a struct, got %T", param.Bind)
+	}
+
+	// copying so caller's maps are left untouched
+	b := &binder{
+		pathParams: map[string]string{},
+		query:      url.Values{},
+		header:     http.Header{},
+		body:       map[string]interface{}{},
+	}
+	for key, value := range param.PathParams {
+		b.pathParams[key] = value
+	}
+	for key, values := range param.QueryValues {
+		b.query[key] = append([]string(nil), values...)
+	}
+	for key, values := range param.HeaderValues {
+		b.header[key] = append([]string(nil), values...)
+	}
+
+	if err := b.bindStruct(v); err != nil {
+		return param, err
+	}
+
+	param.PathParams = b.pathParams
+	param.QueryValues = b.query
+	param.HeaderValues = b.header
+	if param.Body == nil && len(b.body) > 0 {
+		param.Body = b.body
+	}
+
+	return param, nil
+}
+
+// binder accumulates bound values of Parameter.Bind
+type binder struct {
+	pathParams map[string]string
+	query      url.Values
+	header     http.Header
+	body       map[string]interface{}
+}
+
+// bindStruct is a helper to bind every tagged field of a struct, embedded struct included
+func (b *binder) bindStruct(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		// unexported field, embedded one is only walked into when it is an untagged struct,
+		// the same way encoding/json ignores it
+		if field.PkgPath != "" && (!field.Anonymous || hasBindTag(field)) {
+			continue
+		}
+
+		if field.Anonymous && !hasBindTag(field) {
+			embedded := value
+			if embedded.Kind() == reflect.Ptr {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if err := b.bindStruct(embedded); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if err := b.bindField(field, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasBindTag is a helper to check whether a field has any binding tag
+func hasBindTag(field reflect.StructField) bool {
+	for _, tag := range []string{bindTagPath, bindTagQuery, bindTagHeader, bindTagJSON} {
+		if _, ok := field.Tag.Lookup(tag); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// bindField is a helper to bind a single field based on its tags
+func (b *binder) bindField(field reflect.StructField, value reflect.Value) error {
+	isEmpty := isEmptyValue(value)
+
+	if tag, ok := field.Tag.Lookup(bindTagPath); ok {
+		t := parseBindTag(tag)
+		if isEmpty {
+			return &BindError{Field: field.Name, Reason: fmt.Sprintf("path variable %q is required", t.name)}
+		}
+		values, err := formatValues(field, value)
+		if err != nil {
+			return err
+		}
+		if len(values) != 1 {
+			return &BindError{Field: field.Name, Reason: fmt.Sprintf("path variable %q must be a single value", t.name)}
+		}
+		b.pathParams[t.name] = values[0]
+	}
+
+	if tag, ok := field.Tag.Lookup(bindTagQuery); ok {
+		if err := b.bindValues(field, value, isEmpty, parseBindTag(tag), b.query.Add); err != nil {
+			return err
+		}
+	}
+
+	if tag, ok := field.Tag.Lookup(bindTagHeader); ok {
+		if err := b.bindValues(field, value, isEmpty, parseBindTag(tag), b.header.Add); err != nil {
+			return err
+		}
+	}
+
+	if tag, ok := field.Tag.Lookup(bindTagJSON); ok {
+		t := parseBindTag(tag)
+		if t.name == "-" {
+			return nil
+		}
+		if t.required && isEmpty {
+			return &BindError{Field: field.Name, Reason: fmt.Sprintf("json field %q is required", t.name)}
+		}
+		if t.omitEmpty && isEmpty {
+			return nil
+		}
+		if t.name == "" {
+			t.name = field.Name
+		}
+		body, err := jsonValue(field, value)
+		if err != nil {
+			return err
+		}
+		b.body[t.name] = body
+	}
+
+	return nil
+}
+
+// jsonValue is a helper to get the body value of a json field,
+// time.Time with `time_format` tag is formatted the same way as query and header, "unix" and "unixnano" as json number
+func jsonValue(field reflect.StructField, value reflect.Value) (interface{}, error) {
+	layout, ok := field.Tag.Lookup(bindTagTimeFormat)
+	if !ok {
+		return value.Interface(), nil
+	}
+
+	v := value
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	t, ok := v.Interface().(time.Time)
+	if !ok {
+		return nil, &BindError{Field: field.Name, Reason: fmt.Sprintf("time_format is not supported on %s", value.Type())}
+	}
+
+	switch layout {
+	case "unix":
+		return t.Unix(), nil
+	case "unixnano":
+		return t.UnixNano(), nil
+	}
+	return formatValue(field, v)
+}
+
+// bindValues is a helper to bind query or header values
+func (b *binder) bindValues(field reflect.StructField, value reflect.Value, isEmpty bool, t bindTag, add func(key, value string)) error {
+	if isEmpty {
+		if t.required {
+			return &BindError{Field: field.Name, Reason: fmt.Sprintf("%q is required", t.name)}
+		}
+		if t.omitEmpty {
+			return nil
+		}
+	}
+
+	values, err := formatValues(field, value)
+	if err != nil {
+		return err
+	}
+	for _, v := range values {
+		add(t.name, v)
+	}
+	return nil
+}
+
+// isEmptyValue is a helper to check whether a field holds its zero value, nil pointer and empty slice included
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return v.IsZero()
+}
+
+// formatValues is a helper to format a field into string values, slice is formatted per element
+func formatValues(field reflect.StructField, v reflect.Value) ([]string, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8 {
+		values := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elem, err := formatValues(field, v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, elem...)
+		}
+		return values, nil
+	}
+
+	s, err := formatValue(field, v)
+	if err != nil {
+		return nil, err
+	}
+	return []string{s}, nil
+}
+
+// formatValue is a helper to format a single value into string,
+// time.Time follows `time_format` tag (layout, "unix" or "unixnano"), default RFC3339,
+// the tag on any other type is rejected
+func formatValue(field reflect.StructField, v reflect.Value) (string, error) {
+	t, isTime := v.Interface().(time.Time)
+	if _, ok := field.Tag.Lookup(bindTagTimeFormat); ok && !isTime {
+		return "", &BindError{Field: field.Name, Reason: fmt.Sprintf("time_format is not supported on %s", v.Type())}
+	}
+
+	if isTime {
+		switch layout := field.Tag.Get(bindTagTimeFormat); layout {
+		case "":
+			return t.Format(time.RFC3339), nil
+		case "unix":
+			return strconv.FormatInt(t.Unix(), 10), nil
+		case "unixnano":
+			return strconv.FormatInt(t.UnixNano(), 10), nil
+		default:
+			return t.Format(layout), nil
+		}
+	}
+
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		b, err := m.MarshalText()
+		if err != nil {
+			return "", &BindError{Field: field.Name, Reason: err.Error()}
+		}
+		return string(b), nil
+	}
+
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Slice:
+		// []byte
+		return string(v.Bytes()), nil
+	}
+
+	return "", &BindError{Field: field.Name, Reason: fmt.Sprintf("unsupported type %s", v.Type())}
+}
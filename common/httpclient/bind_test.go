@@ -0,0 +1,255 @@
+package httpclient
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testBindPaging struct {
+	Page  int `query:"page,omitempty"`
+	Limit int `query:"limit"`
+}
+
+type testBindRequest struct {
+	testBindPaging
+	UserID   string     `path:"id"`
+	OrderID  int        `path:"orderId"`
+	Status   []string   `query:"status,omitempty"`
+	Since    time.Time  `query:"since,omitempty" time_format:"2006-01-02"`
+	Until    *time.Time `query:"until,omitempty"`
+	Active   *bool      `query:"active,omitempty"`
+	TraceID  string     `header:"x-trace-id,required"`
+	Accept   []string   `header:"Accept,omitempty"`
+	Note     string     `json:"note,omitempty"`
+	Amount   float64    `json:"amount"`
+	internal string
+}
+
+func Test_BindParameter(t *testing.T) {
+	active := true
+	until := time.Date(2021, 10, 17, 1, 2, 3, 0, time.UTC)
+
+	param, err := bindParameter(Parameter{
+		Path: "/users/{id}/orders/{orderId}",
+		Bind: &testBindRequest{
+			testBindPaging: testBindPaging{Limit: 10},
+			UserID:         "some/user",
+			OrderID:        42,
+			Status:         []string{"paid", "shipped"},
+			Since:          time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC),
+			Until:          &until,
+			Active:         &active,
+			TraceID:        "some-trace-id",
+			Accept:         []string{"application/json", "application/xml"},
+			Amount:         1.5,
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"id": "some/user", "orderId": "42"}, param.PathParams)
+	assert.Equal(t, url.Values{
+		"limit":  {"10"},
+		"status": {"paid", "shipped"},
+		"since":  {"2021-10-01"},
+		"until":  {"2021-10-17T01:02:03Z"},
+		"active": {"true"},
+	}, param.QueryValues)
+	assert.Equal(t, http.Header{
+		"X-Trace-Id": {"some-trace-id"},
+		"Accept":     {"application/json", "application/xml"},
+	}, param.HeaderValues)
+	assert.Equal(t, map[string]interface{}{"amount": 1.5}, param.Body)
+}
+
+func Test_BindParameter_TimeFormatUnix(t *testing.T) {
+	type request struct {
+		Since time.Time `query:"since" time_format:"unix"`
+	}
+
+	param, err := bindParameter(Parameter{Bind: request{Since: time.Unix(1634428800, 0)}})
+	require.NoError(t, err)
+	assert.Equal(t, "1634428800", param.QueryValues.Get("since"))
+}
+
+func Test_BindParameter_JSONTimeFormat(t *testing.T) {
+	due := time.Date(2021, 10, 17, 1, 2, 3, 0, time.UTC)
+	type request struct {
+		Due     time.Time  `json:"due" time_format:"2006-01-02"`
+		Since   *time.Time `json:"since" time_format:"unix"`
+		Created time.Time  `json:"created"`
+	}
+
+	param, err := bindParameter(Parameter{Bind: request{Due: due, Since: &due, Created: due}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"due":     "2021-10-17",
+		"since":   due.Unix(),
+		"created": due,
+	}, param.Body)
+
+	type unsupported struct {
+		Note string `json:"note" time_format:"2006-01-02"`
+	}
+	_, err = bindParameter(Parameter{Bind: unsupported{Note: "some-note"}})
+
+	var bindErr *BindError
+	require.True(t, errors.As(err, &bindErr))
+	assert.Equal(t, "Note", bindErr.Field)
+}
+
+func Test_BindParameter_Failed_TimeFormatNotTime(t *testing.T) {
+	testCases := map[string]interface{}{
+		"path": struct {
+			ID int `path:"id" time_format:"2006"`
+		}{ID: 3},
+		"query": struct {
+			N int `query:"n" time_format:"2006"`
+		}{N: 3},
+		"header": struct {
+			N []int `header:"x-n" time_format:"2006"`
+		}{N: []int{3}},
+		"json": struct {
+			N int `json:"n" time_format:"2006"`
+		}{N: 3},
+	}
+
+	for name, bind := range testCases {
+		_, err := bindParameter(Parameter{Bind: bind})
+
+		var bindErr *BindError
+		require.True(t, errors.As(err, &bindErr), name)
+		assert.Contains(t, bindErr.Reason, "time_format is not supported", name)
+	}
+}
+
+// unexportedInner is embedded unexported into bound structs
+type unexportedInner struct {
+	Note string `json:"note"`
+}
+
+// unexportedNumber is embedded unexported into bound structs
+type unexportedNumber int
+
+func Test_BindParameter_UnexportedEmbedded(t *testing.T) {
+	type tagged struct {
+		unexportedInner  `json:"inner"`
+		unexportedNumber `query:"number"`
+		ID               string `query:"id"`
+	}
+	type untagged struct {
+		unexportedInner
+		ID string `query:"id"`
+	}
+
+	param, err := bindParameter(Parameter{Bind: tagged{unexportedInner{Note: "some-note"}, 1, "1"}})
+	require.NoError(t, err)
+	assert.Equal(t, url.Values{"id": {"1"}}, param.QueryValues)
+	assert.Nil(t, param.Body)
+
+	// exported fields of untagged embedded struct are still bound
+	param, err = bindParameter(Parameter{Bind: untagged{unexportedInner{Note: "some-note"}, "1"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"note": "some-note"}, param.Body)
+}
+
+func Test_BindParameter_KeepsExplicitValues(t *testing.T) {
+	type request struct {
+		ID   string `query:"id"`
+		Note string `json:"note"`
+	}
+
+	queryValues := url.Values{"id": {"1"}}
+	param, err := bindParameter(Parameter{
+		QueryValues: queryValues,
+		Body:        "explicit-body",
+		Bind:        request{ID: "2", Note: "some-note"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"1", "2"}, param.QueryValues["id"])
+	assert.Equal(t, "explicit-body", param.Body)
+
+	// caller's map is left untouched
+	assert.Equal(t, url.Values{"id": {"1"}}, queryValues)
+}
+
+func Test_BindParameter_Failed_Required(t *testing.T) {
+	testCases := map[string]interface{}{
+		"path": struct {
+			ID string `path:"id"`
+		}{},
+		"query": struct {
+			Page int `query:"page,required"`
+		}{},
+		"header": struct {
+			TraceID string `header:"x-trace-id,required"`
+		}{},
+		"json": struct {
+			Note string `json:"note,required"`
+		}{},
+	}
+
+	for name, bind := range testCases {
+		_, err := bindParameter(Parameter{Bind: bind})
+
+		var bindErr *BindError
+		require.True(t, errors.As(err, &bindErr), name)
+		assert.Contains(t, bindErr.Reason, "required", name)
+	}
+}
+
+func Test_BindParameter_Failed_NotStruct(t *testing.T) {
+	_, err := bindParameter(Parameter{Bind: "not-a-struct"})
+	require.Error(t, err)
+}
+
+func Test_BindParameter_Failed_UnsupportedType(t *testing.T) {
+	type request struct {
+		Filter map[string]string `query:"filter"`
+	}
+
+	_, err := bindParameter(Parameter{Bind: request{Filter: map[string]string{"a": "b"}}})
+
+	var bindErr *BindError
+	require.True(t, errors.As(err, &bindErr))
+	assert.Equal(t, "Filter", bindErr.Field)
+}
+
+func Test_Post_Bind(t *testing.T) {
+	var received *http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		received, body = r, string(b)
+	}))
+	defer server.Close()
+
+	client := NewHttpClient(Config{Host: server.URL})
+
+	type request struct {
+		ID      string   `path:"id"`
+		Tags    []string `query:"tag"`
+		TraceID string   `header:"x-trace-id"`
+		Note    string   `json:"note"`
+	}
+
+	response, err := client.Post(Parameter{
+		Path: "/users/{id}",
+		Bind: request{ID: "1", Tags: []string{"a", "b"}, TraceID: "some-trace-id", Note: "some-note"},
+	})
+	require.NoError(t, err)
+	response.Body.Close()
+
+	assert.Equal(t, "/users/1", received.URL.Path)
+	assert.Equal(t, "tag=a&tag=b", received.URL.RawQuery)
+	assert.Equal(t, "some-trace-id", received.Header.Get("x-trace-id"))
+	assert.Equal(t, `{"note":"some-note"}`, body)
+}
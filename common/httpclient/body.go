@@ -0,0 +1,73 @@
+package httpclient
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+)
+
+// bodylessMethods are the http methods whose Parameter.Body is never encoded nor sent
+var bodylessMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// isNilBody is a helper to check whether Parameter.Body is empty,
+// typed nil e.g. (map[string]string)(nil) is also considered empty instead of being encoded as "null"
+func isNilBody(body interface{}) bool {
+	if body == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(body)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// bufferBody is a helper to make request body replayable,
+// buffering it into memory when the request has no GetBody
+func bufferBody(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return nil
+	}
+
+	buf, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = int64(len(buf))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(buf)), nil
+	}
+	req.Body, _ = req.GetBody()
+	return nil
+}
+
+// rewindRequest is a helper to clone request with a fresh body for a retry attempt
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return clone, nil
+	}
+
+	if req.GetBody == nil {
+		return nil, ErrBodyNotReplayable
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	clone.Body = body
+
+	return clone, nil
+}
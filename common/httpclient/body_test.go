@@ -0,0 +1,248 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_BufferBody(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, testHost, io.MultiReader(strings.NewReader("some-body")))
+	require.NoError(t, err)
+	require.Nil(t, req.GetBody)
+
+	require.NoError(t, bufferBody(req))
+	require.NotNil(t, req.GetBody)
+	assert.Equal(t, int64(len("some-body")), req.ContentLength)
+
+	for i := 0; i < 2; i++ {
+		body, err := req.GetBody()
+		require.NoError(t, err)
+		b, _ := ioutil.ReadAll(body)
+		assert.Equal(t, "some-body", string(b))
+	}
+}
+
+func Test_RewindRequest(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, testHost, bytes.NewBufferString("some-body"))
+	require.NoError(t, err)
+
+	// drain the original body
+	ioutil.ReadAll(req.Body)
+
+	clone, err := rewindRequest(req)
+	require.NoError(t, err)
+	assert.NotSame(t, req, clone)
+
+	b, _ := ioutil.ReadAll(clone.Body)
+	assert.Equal(t, "some-body", string(b))
+}
+
+func Test_RewindRequest_NotReplayable(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, testHost, io.MultiReader(strings.NewReader("some-body")))
+	require.NoError(t, err)
+
+	_, err = rewindRequest(req)
+	assert.ErrorIs(t, err, ErrBodyNotReplayable)
+}
+
+func Test_Post_Retry_ResendsBody(t *testing.T) {
+	var bodies []string
+	mu := &sync.Mutex{}
+	server := createConfiguredTestServer(testServerConfig{
+		failures: 1,
+		echo:     true,
+		record: func(r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			mu.Lock()
+			defer mu.Unlock()
+			bodies = append(bodies, string(body))
+		},
+	})
+	defer server.Close()
+
+	client := NewHttpClient(Config{
+		Host:                    server.URL,
+		RetryCount:              1,
+		RetryPolicy:             ConstantRetryPolicy{Delay: time.Millisecond},
+		IdempotencyKeyGenerator: NewIdempotencyKey,
+		IsUsingCircuitBreaker:   true,
+	})
+
+	response, err := client.Post(Parameter{
+		Path: "/ping",
+		Body: map[string]string{"someKey": "someValue"},
+	})
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, bodies, 2)
+	assert.Equal(t, `{"someKey":"someValue"}`, bodies[0])
+	assert.Equal(t, bodies[0], bodies[1])
+}
+
+func Test_DoVanilla_Retry_NotReplayableBody(t *testing.T) {
+	var bodies []string
+	mu := &sync.Mutex{}
+	server := createConfiguredTestServer(testServerConfig{
+		failures: 1,
+		echo:     true,
+		record: func(r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			mu.Lock()
+			defer mu.Unlock()
+			bodies = append(bodies, string(body))
+		},
+	})
+	defer server.Close()
+
+	client := NewHttpClient(Config{
+		Host:        server.URL,
+		RetryCount:  1,
+		RetryPolicy: ConstantRetryPolicy{Delay: time.Millisecond},
+	})
+
+	req, err := http.NewRequest(http.MethodPut, server.URL, io.MultiReader(strings.NewReader("some-body")))
+	require.NoError(t, err)
+
+	response, err := client.DoVanilla(req)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrBodyNotReplayable))
+	if response != nil {
+		response.Body.Close()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, bodies, 1)
+}
+
+// recordingListener records every byte read from its accepted connections
+type recordingListener struct {
+	net.Listener
+	mu  *sync.Mutex
+	buf *bytes.Buffer
+}
+
+func (l *recordingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &recordingConn{Conn: conn, listener: l}, nil
+}
+
+// recordingConn records every byte read into its listener
+type recordingConn struct {
+	net.Conn
+	listener *recordingListener
+}
+
+func (c *recordingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.listener.mu.Lock()
+	c.listener.buf.Write(b[:n])
+	c.listener.mu.Unlock()
+	return n, err
+}
+
+// request parses the raw request recorded, into its headers and body
+func (l *recordingListener) request() (http.Header, string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	raw := l.buf.String()
+	i := strings.Index(raw, "\r\n\r\n")
+	head, body := raw[:i], raw[i+4:]
+
+	headers := http.Header{}
+	for _, line := range strings.Split(head, "\r\n")[1:] {
+		kv := strings.SplitN(line, ": ", 2)
+		headers.Add(kv[0], kv[1])
+	}
+	return headers, body
+}
+
+func Test_HttpClient_WireBody(t *testing.T) {
+	type call func(client HttpClient, param Parameter) (*http.Response, error)
+
+	ctx := context.Background()
+	methods := map[string]struct {
+		call     call
+		hasBody  bool
+		wantsLen bool
+	}{
+		"Get":               {call: func(c HttpClient, p Parameter) (*http.Response, error) { return c.Get(p) }},
+		"GetWithContext":    {call: func(c HttpClient, p Parameter) (*http.Response, error) { return c.GetWithContext(ctx, p) }},
+		"Post":              {call: func(c HttpClient, p Parameter) (*http.Response, error) { return c.Post(p) }, hasBody: true, wantsLen: true},
+		"PostWithContext":   {call: func(c HttpClient, p Parameter) (*http.Response, error) { return c.PostWithContext(ctx, p) }, hasBody: true, wantsLen: true},
+		"Put":               {call: func(c HttpClient, p Parameter) (*http.Response, error) { return c.Put(p) }, hasBody: true, wantsLen: true},
+		"PutWithContext":    {call: func(c HttpClient, p Parameter) (*http.Response, error) { return c.PutWithContext(ctx, p) }, hasBody: true, wantsLen: true},
+		"Delete":            {call: func(c HttpClient, p Parameter) (*http.Response, error) { return c.Delete(p) }, hasBody: true},
+		"DeleteWithContext": {call: func(c HttpClient, p Parameter) (*http.Response, error) { return c.DeleteWithContext(ctx, p) }, hasBody: true},
+		"Do(PATCH)":         {call: func(c HttpClient, p Parameter) (*http.Response, error) { return c.Do(http.MethodPatch, p) }, hasBody: true, wantsLen: true},
+		"DoContext(HEAD)":   {call: func(c HttpClient, p Parameter) (*http.Response, error) { return c.DoContext(ctx, http.MethodHead, p) }},
+		"DoContext(OPTIONS)": {call: func(c HttpClient, p Parameter) (*http.Response, error) {
+			return c.DoContext(ctx, http.MethodOptions, p)
+		}},
+	}
+
+	bodies := map[string]interface{}{
+		"nil":       nil,
+		"typed nil": (map[string]string)(nil),
+		"body":      map[string]string{"someKey": "someValue"},
+	}
+
+	for methodName, method := range methods {
+		for bodyName, body := range bodies {
+			t.Run(methodName+"/"+bodyName, func(t *testing.T) {
+				listener := &recordingListener{mu: &sync.Mutex{}, buf: &bytes.Buffer{}}
+				server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					// make sure the whole body has been read off the wire before responding
+					ioutil.ReadAll(r.Body)
+				}))
+				listener.Listener = server.Listener
+				server.Listener = listener
+				server.Start()
+				defer server.Close()
+
+				client := NewHttpClient(Config{Host: server.URL})
+				response, err := method.call(client, Parameter{Path: "/ping", Body: body})
+				require.NoError(t, err)
+				response.Body.Close()
+
+				headers, wireBody := listener.request()
+				if method.hasBody && bodyName == "body" {
+					assert.Equal(t, `{"someKey":"someValue"}`, wireBody)
+					assert.Equal(t, ContentTypeJSON, headers.Get("Content-Type"))
+					assert.Equal(t, "23", headers.Get("Content-Length"))
+					return
+				}
+
+				assert.Empty(t, wireBody)
+				assert.NotContains(t, headers, "Content-Type")
+
+				// net/http still announces empty body for methods expecting one
+				if method.wantsLen {
+					assert.Equal(t, "0", headers.Get("Content-Length"))
+				} else {
+					assert.NotContains(t, headers, "Content-Length")
+				}
+			})
+		}
+	}
+}
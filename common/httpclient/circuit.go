@@ -0,0 +1,294 @@
+package httpclient
+
+import (
+	"sync"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix"
+	metricCollector "github.com/afex/hystrix-go/hystrix/metric_collector"
+	"github.com/afex/hystrix-go/hystrix/rolling"
+)
+
+// CircuitState is the state of a circuit
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"    // requests flow normally
+	CircuitOpen     CircuitState = "open"      // requests are short-circuited
+	CircuitHalfOpen CircuitState = "half-open" // sleep window has elapsed, a single test request is allowed
+)
+
+// CircuitInspector exposes circuits state and metrics of every command key used by a client.
+// it is optionally implemented by HttpClient, use a type assertion to access it, e.g.
+//
+//	if inspector, ok := client.(httpclient.CircuitInspector); ok {
+//		states := inspector.CircuitState()
+//	}
+type CircuitInspector interface {
+	// CircuitState returns state of every circuit, keyed by command key
+	CircuitState() map[string]CircuitState
+
+	// Metrics returns rolling metrics of every circuit, keyed by command key
+	Metrics() map[string]CircuitMetrics
+}
+
+// CircuitMetrics is a snapshot of a circuit over hystrix's 10 seconds rolling window
+type CircuitMetrics struct {
+	CommandKey     string
+	State          CircuitState
+	Requests       int64              // attempts in rolling window
+	Errors         int64              // failed attempts in rolling window, including short-circuits, rejections and timeouts
+	ErrorPercent   int                // errors to requests, 0-100
+	Latency        LatencyPercentiles // run duration of attempts in rolling window
+	LastTransition time.Time          // zero until the circuit has changed state
+}
+
+// LatencyPercentiles is latency distribution of a circuit
+type LatencyPercentiles struct {
+	Mean time.Duration
+	P50  time.Duration
+	P90  time.Duration
+	P99  time.Duration
+}
+
+// circuitCollectors holds *circuitCollector of every circuit used by clients, keyed by command key
+var circuitCollectors = &sync.Map{}
+
+// ownedCircuits holds command keys configured by clients, only their circuits are collected
+var ownedCircuits = &sync.Map{}
+
+// registerCollectorOnce guards registering circuit collector into hystrix
+var registerCollectorOnce = &sync.Once{}
+
+// circuitSinks holds collectors created by Config.CircuitCollector, keyed by command key,
+// circuitCollector forwards every update to them
+var circuitSinks = &sync.Map{}
+
+// circuitSinksMutex guards replacing a collector in circuitSinks
+var circuitSinksMutex = &sync.Mutex{}
+
+// ownCircuit marks circuit of a command key as used by a client, before the circuit's first execution.
+// hystrix creates collectors per circuit on the circuit's creation, circuit collector is registered
+// on the first client's circuit, so programs not using circuit breaker of the client are left untouched
+func ownCircuit(key string) {
+	ownedCircuits.Store(key, struct{}{})
+	registerCollectorOnce.Do(func() {
+		metricCollector.Registry.Register(newCircuitCollector)
+	})
+}
+
+// circuitCollector is a hystrix metric collector recording what CircuitMetrics needs
+type circuitCollector struct {
+	name     string
+	mutex    *sync.RWMutex
+	requests *rolling.Number
+	errors   *rolling.Number
+	latency  *rolling.Timing
+}
+
+// newCircuitCollector initiates circuit collector of a command key
+func newCircuitCollector(name string) metricCollector.MetricCollector {
+	// circuits used through hystrix directly are not collected
+	if _, ok := ownedCircuits.Load(name); !ok {
+		return nopCircuitCollector{}
+	}
+
+	collector := &circuitCollector{name: name, mutex: &sync.RWMutex{}}
+	collector.Reset()
+	circuitCollectors.Store(name, collector)
+	return collector
+}
+
+// Update records result of a command execution
+func (c *circuitCollector) Update(r metricCollector.MetricResult) {
+	c.mutex.RLock()
+	c.requests.Increment(r.Attempts)
+	c.errors.Increment(r.Errors)
+	c.latency.Add(r.RunDuration)
+	c.mutex.RUnlock()
+
+	if sink, ok := circuitSinks.Load(c.name); ok {
+		sink.(metricCollector.MetricCollector).Update(r)
+	}
+}
+
+// Reset clears the metrics, hystrix does it whenever a circuit closes
+func (c *circuitCollector) Reset() {
+	c.mutex.Lock()
+	c.requests = rolling.NewNumber()
+	c.errors = rolling.NewNumber()
+	c.latency = rolling.NewTiming()
+	c.mutex.Unlock()
+
+	if sink, ok := circuitSinks.Load(c.name); ok {
+		sink.(metricCollector.MetricCollector).Reset()
+	}
+}
+
+// nopCircuitCollector is the hystrix metric collector of circuits not used by clients
+type nopCircuitCollector struct{}
+
+func (nopCircuitCollector) Update(r metricCollector.MetricResult) {}
+func (nopCircuitCollector) Reset()                                {}
+
+// snapshot fills metric values of CircuitMetrics
+func (c *circuitCollector) snapshot(metrics *CircuitMetrics, now time.Time) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	metrics.Requests = int64(c.requests.Sum(now))
+	metrics.Errors = int64(c.errors.Sum(now))
+	if metrics.Requests > 0 {
+		metrics.ErrorPercent = int(float64(metrics.Errors) / float64(metrics.Requests) * 100)
+	}
+	metrics.Latency = LatencyPercentiles{
+		Mean: time.Duration(c.latency.Mean()) * time.Millisecond,
+		P50:  time.Duration(c.latency.Percentile(50)) * time.Millisecond,
+		P90:  time.Duration(c.latency.Percentile(90)) * time.Millisecond,
+		P99:  time.Duration(c.latency.Percentile(99)) * time.Millisecond,
+	}
+}
+
+// circuitTracker follows state of a circuit as seen by the client,
+// hystrix doesn't expose half-open nor when a circuit has transitioned
+type circuitTracker struct {
+	mutex          *sync.Mutex
+	state          CircuitState
+	openedAt       time.Time // opened or last tested, mirroring hystrix's sleep window reference
+	isTesting      bool      // a single test request is in flight
+	lastTransition time.Time
+}
+
+// newCircuitTracker initiates tracker of a closed circuit
+func newCircuitTracker() *circuitTracker {
+	return &circuitTracker{
+		mutex: &sync.Mutex{},
+		state: CircuitClosed,
+	}
+}
+
+// observe updates tracked state against hystrix's circuit and returns the state before and after
+func (t *circuitTracker) observe(isOpen bool, sleepWindow time.Duration, now time.Time) (CircuitState, CircuitState) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	from := t.state
+	to := CircuitClosed
+	if isOpen {
+		if from == CircuitClosed {
+			t.openedAt = now
+		}
+
+		to = CircuitOpen
+		if t.isTesting || now.Sub(t.openedAt) > sleepWindow {
+			to = CircuitHalfOpen
+		}
+	}
+
+	if to != from {
+		t.state = to
+		t.lastTransition = now
+	}
+	return from, to
+}
+
+// beginTest marks an attempt admitted while circuit is not closed, which is hystrix's single test request.
+// it returns false when circuit is closed
+func (t *circuitTracker) beginTest(now time.Time) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.state == CircuitClosed {
+		return false
+	}
+	t.isTesting = true
+	t.openedAt = now
+	return true
+}
+
+// endTest marks the single test request as finished
+func (t *circuitTracker) endTest() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.isTesting = false
+}
+
+// current returns tracked state
+func (t *circuitTracker) current() CircuitState {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.state
+}
+
+// transitionedAt returns time of the last transition
+func (t *circuitTracker) transitionedAt() time.Time {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.lastTransition
+}
+
+// circuitTracker returns tracker of a command key
+func (hc *Client) circuitTracker(key string) *circuitTracker {
+	if tracker, ok := hc.commands.Load(key); ok {
+		return tracker.(*circuitTracker)
+	}
+
+	tracker, _ := hc.commands.LoadOrStore(key, newCircuitTracker())
+	return tracker.(*circuitTracker)
+}
+
+// observeCircuit checks hystrix's circuit of a command key and updates its tracked state,
+// emitting event on transition
+func (hc *Client) observeCircuit(key string) (CircuitState, CircuitState) {
+	tracker := hc.circuitTracker(key)
+
+	circuit, _, err := hystrix.GetCircuit(key)
+	if err != nil {
+		state := tracker.current()
+		return state, state
+	}
+
+	sleepWindow := time.Duration(hc.config.CbConfig.SleepWindow) * time.Millisecond
+	from, to := tracker.observe(circuit.IsOpen(), sleepWindow, time.Now())
+	hc.config.MetricsCollector.SetCircuitState(hc.config.Name, key, to)
+	hc.emitTransition(key, from, to)
+	return from, to
+}
+
+// CircuitState returns state of every circuit used by the client, keyed by command key
+func (hc *Client) CircuitState() map[string]CircuitState {
+	states := map[string]CircuitState{}
+	hc.commands.Range(func(key, _ interface{}) bool {
+		_, state := hc.observeCircuit(key.(string))
+		states[key.(string)] = state
+		return true
+	})
+	return states
+}
+
+// Metrics returns rolling metrics of every circuit used by the client, keyed by command key
+func (hc *Client) Metrics() map[string]CircuitMetrics {
+	now := time.Now()
+
+	metrics := map[string]CircuitMetrics{}
+	hc.commands.Range(func(key, value interface{}) bool {
+		commandKey := key.(string)
+		_, state := hc.observeCircuit(commandKey)
+
+		metric := CircuitMetrics{
+			CommandKey:     commandKey,
+			State:          state,
+			LastTransition: value.(*circuitTracker).transitionedAt(),
+		}
+		if collector, ok := circuitCollectors.Load(commandKey); ok {
+			collector.(*circuitCollector).snapshot(&metric, now)
+		}
+
+		metrics[commandKey] = metric
+		return true
+	})
+	return metrics
+}
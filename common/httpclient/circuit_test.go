@@ -0,0 +1,126 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Client_ImplementsCircuitInspector(t *testing.T) {
+	var client HttpClient = NewHttpClient(Config{Host: "http://localhost"})
+
+	_, ok := client.(CircuitInspector)
+	assert.True(t, ok)
+}
+
+func Test_CircuitTracker_Observe(t *testing.T) {
+	sleepWindow := time.Second
+	now := time.Now()
+	tracker := newCircuitTracker()
+
+	from, to := tracker.observe(false, sleepWindow, now)
+	assert.Equal(t, CircuitClosed, from)
+	assert.Equal(t, CircuitClosed, to)
+	assert.True(t, tracker.transitionedAt().IsZero())
+
+	from, to = tracker.observe(true, sleepWindow, now)
+	assert.Equal(t, CircuitClosed, from)
+	assert.Equal(t, CircuitOpen, to)
+	assert.Equal(t, now, tracker.transitionedAt())
+
+	_, to = tracker.observe(true, sleepWindow, now.Add(sleepWindow/2))
+	assert.Equal(t, CircuitOpen, to)
+
+	_, to = tracker.observe(true, sleepWindow, now.Add(2*sleepWindow))
+	assert.Equal(t, CircuitHalfOpen, to)
+
+	// failed test request sends the circuit back to open for another sleep window
+	testedAt := now.Add(2 * sleepWindow)
+	require.True(t, tracker.beginTest(testedAt))
+	tracker.endTest()
+	_, to = tracker.observe(true, sleepWindow, testedAt.Add(time.Millisecond))
+	assert.Equal(t, CircuitOpen, to)
+
+	// successful test request closes it
+	require.True(t, tracker.beginTest(testedAt.Add(2*sleepWindow)))
+	tracker.endTest()
+	_, to = tracker.observe(false, sleepWindow, testedAt.Add(2*sleepWindow))
+	assert.Equal(t, CircuitClosed, to)
+	assert.False(t, tracker.beginTest(testedAt.Add(2*sleepWindow)))
+}
+
+func Test_Client_CircuitStateAndMetrics(t *testing.T) {
+	status := int32(http.StatusInternalServerError)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+	}))
+	defer server.Close()
+
+	commandName := createCommandName(t)
+	client := NewHttpClient(Config{
+		Host:                  server.URL,
+		IsUsingCircuitBreaker: true,
+		CommandNamer:          CommandNamerByName(commandName),
+		CbConfig: CircuitBreakerConfig{
+			SleepWindow:            100,
+			RequestVolumeThreshold: 3,
+		},
+	})
+	inspector := client.(CircuitInspector)
+	assert.Empty(t, inspector.CircuitState())
+
+	for i := 0; i < 3; i++ {
+		response, err := client.Get(Parameter{Path: "/ping"})
+		require.Error(t, err)
+		require.NotNil(t, response)
+		response.Body.Close()
+	}
+
+	require.Eventually(t, func() bool {
+		return inspector.CircuitState()[commandName] == CircuitOpen
+	}, time.Second, 10*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		return inspector.Metrics()[commandName].Requests == 3
+	}, time.Second, 10*time.Millisecond)
+
+	metrics := inspector.Metrics()[commandName]
+	assert.Equal(t, commandName, metrics.CommandKey)
+	assert.Equal(t, CircuitOpen, metrics.State)
+	assert.Equal(t, int64(3), metrics.Errors)
+	assert.Equal(t, 100, metrics.ErrorPercent)
+	assert.GreaterOrEqual(t, metrics.Latency.P99, metrics.Latency.P50)
+	assert.False(t, metrics.LastTransition.IsZero())
+
+	require.Eventually(t, func() bool {
+		return inspector.CircuitState()[commandName] == CircuitHalfOpen
+	}, time.Second, 10*time.Millisecond)
+
+	atomic.StoreInt32(&status, http.StatusOK)
+	response, err := client.Get(Parameter{Path: "/ping"})
+	require.NoError(t, err)
+	require.NotNil(t, response)
+	response.Body.Close()
+
+	require.Eventually(t, func() bool {
+		return inspector.CircuitState()[commandName] == CircuitClosed
+	}, time.Second, 10*time.Millisecond)
+}
+
+func Test_CircuitCollector_OnlyClientCircuits(t *testing.T) {
+	ownCircuit(createCommandName(t))
+
+	commandName := createCommandName(t)
+	err := hystrix.Do(commandName, func() error { return nil }, nil)
+	require.NoError(t, err)
+
+	_, ok := circuitCollectors.Load(commandName)
+	assert.False(t, ok)
+}
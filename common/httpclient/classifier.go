@@ -0,0 +1,13 @@
+package httpclient
+
+import "net/http"
+
+// ResponseClassifier decides whether a received response is counted as a failure,
+// failures are fed to the circuit breaker, retried and returned as *HTTPError
+type ResponseClassifier func(res *http.Response) bool
+
+// DefaultResponseClassifier flags 5xx and 429 responses as failures,
+// other 4xx are considered caller's mistake and not the dependency's failure
+func DefaultResponseClassifier(res *http.Response) bool {
+	return res.StatusCode >= http.StatusInternalServerError || res.StatusCode == http.StatusTooManyRequests
+}
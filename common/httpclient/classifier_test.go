@@ -0,0 +1,126 @@
+package httpclient
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DefaultResponseClassifier(t *testing.T) {
+	testCases := map[int]bool{
+		http.StatusOK:                  false,
+		http.StatusNoContent:           false,
+		http.StatusBadRequest:          false,
+		http.StatusNotFound:            false,
+		http.StatusTooManyRequests:     true,
+		http.StatusInternalServerError: true,
+		http.StatusServiceUnavailable:  true,
+	}
+
+	for status, expected := range testCases {
+		assert.Equal(t, expected, DefaultResponseClassifier(&http.Response{StatusCode: status}), "status %d", status)
+	}
+}
+
+func Test_Get_Failed_ServerError_IsRetried(t *testing.T) {
+	var hits int32
+	server := createConfiguredTestServer(testServerConfig{
+		status: http.StatusInternalServerError,
+		body:   `{ "response": "not ok" }`,
+		hits:   &hits,
+	})
+	defer server.Close()
+
+	client := NewHttpClient(Config{
+		Host:       server.URL,
+		RetryCount: 1,
+	})
+
+	response, err := client.Get(Parameter{Path: "/ping"})
+	require.Error(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+
+	var httpErr *HTTPError
+	require.True(t, errors.As(err, &httpErr))
+	assert.Equal(t, http.StatusInternalServerError, httpErr.StatusCode())
+	assert.Same(t, response, httpErr.Response)
+
+	body, err := ioutil.ReadAll(response.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{ "response": "not ok" }`, string(body))
+}
+
+func Test_Get_ClientError_IsNotFailure(t *testing.T) {
+	var hits int32
+	server := createConfiguredTestServer(testServerConfig{status: http.StatusNotFound, hits: &hits})
+	defer server.Close()
+
+	client := NewHttpClient(Config{
+		Host:       server.URL,
+		RetryCount: 1,
+	})
+
+	response, err := client.Get(Parameter{Path: "/ping"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
+
+func Test_Get_CustomResponseClassifier(t *testing.T) {
+	var hits int32
+	server := createConfiguredTestServer(testServerConfig{status: http.StatusNotFound, hits: &hits})
+	defer server.Close()
+
+	client := NewHttpClient(Config{
+		Host: server.URL,
+		ResponseClassifier: func(res *http.Response) bool {
+			return res.StatusCode >= http.StatusBadRequest
+		},
+	})
+
+	_, err := client.Get(Parameter{Path: "/ping"})
+
+	var httpErr *HTTPError
+	require.True(t, errors.As(err, &httpErr))
+	assert.Equal(t, http.StatusNotFound, httpErr.StatusCode())
+}
+
+func Test_Circuit_OpensOnServerErrors(t *testing.T) {
+	var hits int32
+	server := createConfiguredTestServer(testServerConfig{status: http.StatusServiceUnavailable, hits: &hits})
+	defer server.Close()
+
+	commandName := createCommandName(t)
+	client := NewHttpClient(Config{
+		Host:                  server.URL,
+		IsUsingCircuitBreaker: true,
+		CommandNamer:          CommandNamerByName(commandName),
+		CbConfig: CircuitBreakerConfig{
+			SleepWindow:            60000,
+			RequestVolumeThreshold: 3,
+		},
+	})
+
+	for i := 0; i < 3; i++ {
+		response, err := client.Get(Parameter{Path: "/ping"})
+
+		var httpErr *HTTPError
+		require.True(t, errors.As(err, &httpErr))
+		response.Body.Close()
+	}
+
+	circuit, _, err := hystrix.GetCircuit(commandName)
+	require.NoError(t, err)
+	require.Eventually(t, circuit.IsOpen, time.Second, 10*time.Millisecond)
+
+	_, err = client.Get(Parameter{Path: "/ping"})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+}
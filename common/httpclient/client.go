@@ -1,13 +1,15 @@
 package httpclient
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/afex/hystrix-go/hystrix"
@@ -16,43 +18,97 @@ import (
 // Client is a wrapper around net/http
 // which have circuit-breaker alike functionality
 type Client struct {
-	client *http.Client // http client, using native golang net's http
-	config Config       // configs
+	client   *http.Client // http client, using native golang net's http
+	config   Config       // configs
+	commands *sync.Map    // circuit breaker command keys which have been configured, with their *circuitTracker
+	doer     Doer         // full execution chain, built-in behaviours + middlewares
 }
 
 // CircuitBreakerConfig is the circuit breaker's configuration implemented
 // inside the HttpClient wrapper
 type CircuitBreakerConfig struct {
-	SleepWindow    int                                // in ms, to wait after a circuit opens before testing for recovery
-	ErrorThreshold int                                // minimum number of requests needed before a circuit can be tripped
-	Timeout        int                                // in ms, how long to wait for command to complete
-	Fallback       func(context.Context, error) error // custom fallback function
+	SleepWindow            int                                // in ms, to wait after a circuit opens before testing for recovery
+	RequestVolumeThreshold int                                // minimum number of requests in rolling window needed before a circuit can be tripped
+	ErrorPercentThreshold  int                                // 1-100, circuit opens once the rolling error percentage reaches it, default hystrix's 50
+	MaxConcurrentRequests  int                                // maximum concurrent attempts per circuit, excess is rejected with ErrMaxConcurrency, default hystrix's 10
+	ErrorThreshold         int                                // Deprecated: misnamed, it is RequestVolumeThreshold
+	Timeout                int                                // in ms, how long to wait for command to complete
+	Fallback               func(context.Context, error) error // custom fallback function
+	ResponseFallback       ResponseFallback                   // custom fallback serving a degraded response once retries are exhausted, takes precedence over Fallback
 }
 
 // default values for CircuitBreakerConfig
 const (
-	defautCbSleepWindow     = 5000
-	defaultCbErrorThreshold = 20
-	defaultCbTimeout        = 10000
+	defautCbSleepWindow             = 5000
+	defaultCbRequestVolumeThreshold = 20
+	defaultCbTimeout                = 10000
 )
 
 // Config is the HttpClient configuration
 type Config struct {
-	Host                  string                    // external host
-	Timeout               time.Duration             // http request timeout
-	RetryCount            int                       // failing http request retry
-	OnPreRetryCallback    func(*http.Request) error // callback called on every pre-retry
-	IsUsingCircuitBreaker bool                      // flag to use circuit breaker, true = on, false = off
-	CbConfig              CircuitBreakerConfig      // custom config for circuit breaker
+	Name                    string                    // client name, labelling metrics, default Host
+	Host                    string                    // external host
+	Timeout                 time.Duration             // http request timeout
+	ContentType             string                    // default content type of Parameter.Body, selecting its Codec, default application/json
+	RetryCount              int                       // failing http request retry
+	RetryPolicy             RetryPolicy               // wait time between retries, default linear 1s step
+	IdempotencyKeyGenerator func() string             // generates Idempotency-Key header for non-idempotent requests, making them retryable, default off
+	OnPreRetryCallback      func(*http.Request) error // callback called on every pre-retry
+	IsUsingCircuitBreaker   bool                      // flag to use circuit breaker, true = on, false = off
+	CbConfig                CircuitBreakerConfig      // custom config for circuit breaker
+	CommandNamer            CommandNamer              // strategy to resolve circuit breaker command key, default per host
+	ResponseClassifier      ResponseClassifier        // decides which response counts as failure, default 5xx and 429
+	CallMiddlewares         []Middleware              // wraps the whole logical call, outside retries
+	AttemptMiddlewares      []Middleware              // wraps every single attempt, inside circuit breaker
+	EventListeners          []EventListener           // receives circuit transitions, fallbacks, retries and rejections
+	MetricsCollector        MetricsCollector          // records requests, retries, fallbacks and circuits, default off
+	CircuitCollector        CircuitCollectorFactory   // ships hystrix metrics of the client's circuits, e.g. to StatsD, default off
+	Logger                  Logger                    // logs circuit events and, with RequestLog, requests and responses, default off
+	RequestLog              *RequestLogConfig         // logs every attempt's request and response, default off
+	Hedging                 *HedgingConfig            // hedges slow GET and HEAD requests, default off
 }
 
 // default values for Config
 const (
-	defautTimeout = 10 * time.Second
+	defautTimeout          = 10 * time.Second
+	defaultRetryLinearStep = 1 * time.Second
 )
 
-// NewHttpClient initialises the Client handle that is used to wrap net/http
+// NewHttpClient initialises the Client handle that is used to wrap net/http,
+// invalid config fields are logged and replaced by their defaults,
+// use NewHttpClientE to handle them as error instead
 func NewHttpClient(config Config) HttpClient {
+	logger := config.Logger
+	if logger == nil {
+		logger = NewStdLogger(os.Stderr, LevelWarn)
+	}
+
+	for {
+		client, err := NewHttpClientE(config)
+		var configErr *ConfigError
+		if !errors.As(err, &configErr) {
+			return client
+		}
+
+		logger.Warn("httpclient invalid config, using default", "field", configErr.Field, "reason", configErr.Reason)
+		defaulted, ok := config.withDefault(configErr.Field)
+		if !ok {
+			// field can't be dropped on its own, falling back to the whole default config
+			logger.Error("httpclient invalid config, using default config", "field", configErr.Field, "reason", configErr.Reason)
+			client, _ := NewHttpClientE(Config{Logger: config.Logger})
+			return client
+		}
+		config = defaulted
+	}
+}
+
+// NewHttpClientE initialises the Client handle that is used to wrap net/http,
+// returning *ConfigError on invalid config
+func NewHttpClientE(config Config) (HttpClient, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
 	// force delete '/' at host's value suffix
 	config.Host = strings.TrimSuffix(config.Host, "/")
 
@@ -69,6 +125,21 @@ func NewHttpClient(config Config) HttpClient {
 		}
 	}
 
+	// set default value if default config not defined
+	if config.ContentType == "" {
+		config.ContentType = ContentTypeJSON
+	}
+
+	// set default value if default config not defined
+	if config.RetryPolicy == nil {
+		config.RetryPolicy = LinearRetryPolicy{Step: defaultRetryLinearStep}
+	}
+
+	// set default value if default config not defined
+	if config.ResponseClassifier == nil {
+		config.ResponseClassifier = DefaultResponseClassifier
+	}
+
 	// configure circuit breaker
 	if config.IsUsingCircuitBreaker {
 		// set default value if not defined
@@ -76,9 +147,14 @@ func NewHttpClient(config Config) HttpClient {
 			config.CbConfig.SleepWindow = defautCbSleepWindow
 		}
 
+		// deprecated field is still honoured
+		if config.CbConfig.RequestVolumeThreshold == 0 {
+			config.CbConfig.RequestVolumeThreshold = config.CbConfig.ErrorThreshold
+		}
+
 		// set default value if not defined
-		if config.CbConfig.ErrorThreshold == 0 {
-			config.CbConfig.ErrorThreshold = defaultCbErrorThreshold
+		if config.CbConfig.RequestVolumeThreshold == 0 {
+			config.CbConfig.RequestVolumeThreshold = defaultCbRequestVolumeThreshold
 		}
 
 		// set default value if not defined
@@ -94,71 +170,119 @@ func NewHttpClient(config Config) HttpClient {
 			config.CbConfig.Timeout = defaultCbTimeout
 		}
 
-		// initialize circuit breaker
-		// using afex/hystrix-go lib
-		// please check hystrix-go lib for further usage
-		hystrix.ConfigureCommand(config.Host, hystrix.CommandConfig{
-			Timeout:                config.CbConfig.Timeout,
-			SleepWindow:            config.CbConfig.SleepWindow,
-			RequestVolumeThreshold: config.CbConfig.ErrorThreshold,
-		})
 	}
 
-	return &Client{
-		config: config,
-		client: &http.Client{Timeout: config.Timeout},
+	// set default value if default config not defined
+	if config.Name == "" {
+		config.Name = config.Host
 	}
+
+	// set default value if default config not defined
+	if config.Logger == nil {
+		config.Logger = NopLogger{}
+	}
+
+	// set default value if default config not defined
+	if config.MetricsCollector == nil {
+		config.MetricsCollector = nopMetricsCollector{}
+	}
+
+	// set default value if default config not defined
+	if config.CommandNamer == nil {
+		config.CommandNamer = CommandNamerByHost()
+	}
+
+	// circuit breaker itself is initialized lazily per command key on execution,
+	// using afex/hystrix-go lib
+	// please check hystrix-go lib for further usage
+	hc := &Client{
+		config:   config,
+		client:   &http.Client{Timeout: config.Timeout},
+		commands: &sync.Map{},
+	}
+	hc.doer = hc.chain()
+
+	return hc, nil
 }
 
 // Parameter is a struct consists of the HttpClient basic payload
 type Parameter struct {
-	Path          string
-	PathVariables []string
+	Path          string            // path or route template with named variables, e.g. "/users/{id}", also used as circuit breaker and metrics label
+	PathParams    map[string]string // named path variables of Path, escaped per segment
+	PathVariables []string          // path segments appended after Path, escaped per segment
 	QueryParams   map[string]string
+	QueryValues   url.Values // multi-value query params, e.g. ?id=1&id=2, added after QueryParams
 	Header        map[string]string
+	HeaderValues  http.Header // multi-value headers, e.g. repeated Accept, added after Header
 	Body          interface{}
+	ContentType   string      // content type of Body, selecting its Codec, default Config.ContentType
+	Bind          interface{} // struct tagged with `path`, `query`, `header` and `json`, merged into the fields above
 }
 
-// addQueryString is a helper to add query string to the request
-func addQueryString(req *http.Request, queryStrings map[string]string) {
+// addQueryString is a helper to add query string to the request.
+// encoded query is sorted by key while values of the same key keep their order,
+// giving a canonical query string for request signing
+func addQueryString(req *http.Request, queryStrings map[string]string, queryValues url.Values) {
 	q := req.URL.Query()
 	for key, value := range queryStrings {
 		q.Add(key, value)
 	}
+	for key, values := range queryValues {
+		for _, value := range values {
+			q.Add(key, value)
+		}
+	}
 	req.URL.RawQuery = q.Encode()
 }
 
-// generateBody is a helper to convert header map to http.Header
-func generateHeaders(headersPlain map[string]string) http.Header {
+// generateHeaders is a helper to convert header map to http.Header,
+// values are added, not set, so repeated headers are kept in order
+func generateHeaders(headersPlain map[string]string, headerValues http.Header) http.Header {
 	headers := http.Header{}
 	for key, value := range headersPlain {
-		headers.Set(key, value)
+		headers.Add(key, value)
+	}
+	for key, values := range headerValues {
+		for _, value := range values {
+			headers.Add(key, value)
+		}
 	}
 	return headers
 }
 
-// generateBody is a helper to convert body object to io.Reader
-func generateBody(bodyPlain interface{}) (io.Reader, error) {
-	jsonBody, err := json.Marshal(bodyPlain)
+// generateBody is a helper to convert body object to io.Reader using the content type's codec,
+// returning nil reader when the request should be sent without body
+func generateBody(httpMethod string, bodyPlain interface{}, contentType string) (io.Reader, error) {
+	if bodylessMethods[httpMethod] || isNilBody(bodyPlain) {
+		return nil, nil
+	}
+
+	codec, err := CodecFor(contentType)
 	if err != nil {
 		return nil, err
 	}
-	return bytes.NewBuffer(jsonBody), nil
+	return codec.Encode(bodyPlain)
 }
 
-// generateUrl is a helper to combine url + path + path-variables
-func (hc *Client) generateUrl(param Parameter) string {
+// generateUrl is a helper to combine url + path + path-variables,
+// resolving named path variables of the path template
+func (hc *Client) generateUrl(param Parameter) (string, error) {
+	path, err := resolvePath(param.Path, param.PathParams)
+	if err != nil {
+		return "", err
+	}
+
 	fullPath := hc.config.Host
-	if len(param.Path) > 0 && !strings.HasPrefix(param.Path, "/") {
+	if len(path) > 0 && !strings.HasPrefix(path, "/") {
 		fullPath = fullPath + "/"
 	}
-	fullPath = fullPath + param.Path
+	fullPath = fullPath + path
 
 	if len(param.PathVariables) > 0 {
-		fullPath = fmt.Sprintf("%s/%s", fullPath, strings.Join(param.PathVariables, "/"))
+		fullPath = fmt.Sprintf("%s/%s", fullPath, escapePathVariables(param.PathVariables))
 	}
 
-	return fullPath
+	return fullPath, nil
 }
 
 // Get executes an http request with method GET
@@ -224,12 +348,43 @@ func (hc *Client) Do(httpMethod string, param Parameter) (*http.Response, error)
 // with context in args
 func (hc *Client) DoContext(ctx context.Context, httpMethod string, param Parameter) (*http.Response, error) {
 
-	fullUrl := hc.generateUrl(param)
-	headers := generateHeaders(param.Header)
-	body, err := generateBody(param.Body)
+	param, err := bindParameter(param)
+	if err != nil {
+		return nil, err
+	}
+
+	fullUrl, err := hc.generateUrl(param)
 	if err != nil {
 		return nil, err
 	}
+	headers := generateHeaders(param.Header, param.HeaderValues)
+
+	contentType := param.ContentType
+	if contentType == "" {
+		contentType = hc.config.ContentType
+	}
+
+	// multipart body is streamed separately, not encoded by codec
+	multipartBody, isMultipart := asMultipart(param.Body)
+	if bodylessMethods[httpMethod] {
+		isMultipart = false
+	}
+
+	var body io.Reader
+	if !isMultipart {
+		body, err = generateBody(httpMethod, param.Body, contentType)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// explicit header from caller takes precedence
+	if body != nil && headers.Get("Content-Type") == "" {
+		headers.Set("Content-Type", contentType)
+	}
+
+	// carry route template for circuit breaker command key
+	ctx = withRouteTemplate(ctx, param.Path)
 
 	// initialize request
 	req, errReq := http.NewRequestWithContext(ctx, httpMethod, fullUrl, body)
@@ -238,11 +393,19 @@ func (hc *Client) DoContext(ctx context.Context, httpMethod string, param Parame
 	}
 
 	// add query strings
-	addQueryString(req, param.QueryParams)
+	addQueryString(req, param.QueryParams, param.QueryValues)
 
 	// add headers
 	req.Header = headers
 
+	// make sure body can be resent on retry,
+	// multipart body is never buffered, it is only resent when its parts are re-openable
+	if isMultipart {
+		setMultipartBody(req, multipartBody)
+	} else if errBuffer := bufferBody(req); errBuffer != nil {
+		return nil, errBuffer
+	}
+
 	return hc.DoVanilla(req)
 }
 
@@ -251,58 +414,245 @@ func (hc *Client) DoContext(ctx context.Context, httpMethod string, param Parame
 // wrapped with circuit breaker functionality and retry mechanism
 // with fully customized http.Request param
 func (hc *Client) DoVanilla(req *http.Request) (*http.Response, error) {
-	// execute request
-	res, errRes := hc.doActual(req)
+	return hc.doer.Do(req)
+}
+
+// idempotencyMiddleware attaches idempotency key once for every attempts of a logical call
+func (hc *Client) idempotencyMiddleware(next Doer) Doer {
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		return next.Do(hc.withIdempotencyKey(req))
+	})
+}
 
-	// request validation
-	if errRes != nil {
-		// retry mechanism
+// retryMiddleware is the retry mechanism, re-executing failing request based on RetryPolicy.
+// request still failing after being retried is returned as *RetryError
+func (hc *Client) retryMiddleware(next Doer) Doer {
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		// execute request
+		start := time.Now()
+		res, errRes := next.Do(req)
+
+		// request validation,
+		// only idempotent request is retried to avoid duplicate side effects on downstream
+		if errRes == nil || !isRetryable(req) {
+			return res, errRes
+		}
+
+		attempts := []error{errRes}
+		var wait time.Duration
 		for i := 0; i < hc.config.RetryCount; i++ {
 
+			// wait time for retry, policy may decide to stop retrying
+			var isRetrying bool
+			wait, isRetrying = hc.config.RetryPolicy.Backoff(i+1, wait, time.Since(start))
+			if !isRetrying {
+				break
+			}
+
+			// every retry is a fresh clone with a rewound body,
+			// the original body has been consumed by previous attempt
+			retryReq, errRewind := rewindRequest(req)
+			if errRewind != nil {
+				// refusing to retry with a drained body
+				attempts = append(attempts, errRewind)
+				break
+			}
+
+			// discard failing response before retrying
+			if res != nil {
+				res.Body.Close()
+				res = nil
+			}
+
 			// pre-retry callback
-			errRetryCallback := hc.config.OnPreRetryCallback(req)
+			errRetryCallback := hc.config.OnPreRetryCallback(retryReq)
 			if errRetryCallback != nil {
 				// failing on pre-retry callback will stop the retry mechanism
-				errRes = errRetryCallback
+				attempts = append(attempts, errRetryCallback)
+				break
+			}
+
+			// cancelled request will stop the retry mechanism
+			if errSleep := sleepContext(req.Context(), wait); errSleep != nil {
+				attempts = append(attempts, errSleep)
 				break
 			}
 
-			// exponential wait time for retry
-			time.Sleep(time.Duration(i+1) * time.Second)
+			hc.config.MetricsCollector.IncRetry(hc.metricLabels(retryReq))
+			hc.emit(Event{
+				Type:       EventRetry,
+				CommandKey: hc.config.CommandNamer(retryReq),
+				Reason:     errRes.Error(),
+				Err:        errRes,
+				Attempt:    i + 1,
+			})
 
 			// re-execute request
-			res, errRes = hc.doActual(req)
+			res, errRes = next.Do(retryReq)
 
 			//success retry will break the loop
 			if errRes == nil {
-				break
+				return res, nil
 			}
+			attempts = append(attempts, errRes)
+		}
+
+		if len(attempts) == 1 {
+			return res, attempts[0]
+		}
+		return res, &RetryError{Attempts: attempts}
+	})
+}
+
+// responseFallbackMiddleware serves ResponseFallback once the call has failed, retries included
+func (hc *Client) responseFallbackMiddleware(next Doer) Doer {
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		res, errCause := next.Do(req)
+		if errCause == nil {
+			return res, nil
+		}
+
+		// degraded response, failing to serve one keeps the original error
+		ctx := withResponseClassifier(req.Context(), hc.config.ResponseClassifier)
+		fallbackResponse, errFallback := hc.config.CbConfig.ResponseFallback(ctx, req, errCause)
+		if errFallback == nil && fallbackResponse == nil {
+			return res, errCause
+		}
+
+		commandKey := hc.config.CommandNamer(req)
+		hc.config.MetricsCollector.IncFallback(hc.metricLabels(req))
+		hc.emit(Event{Type: EventFallback, CommandKey: commandKey, Reason: errCause.Error(), Err: errCause})
+
+		if errFallback != nil {
+			return res, &FallbackError{Err: errFallback, Cause: errCause}
 		}
-	}
 
-	return res, errRes
+		// fallback response replaces the failing one
+		if res != nil {
+			res.Body.Close()
+		}
+		markFallbackResponse(req, fallbackResponse)
+		return fallbackResponse, nil
+	})
+}
+
+// circuitBreakerMiddleware wraps every attempt with circuit breaker functionality,
+// hystrix errors are returned as *CircuitError
+func (hc *Client) circuitBreakerMiddleware(next Doer) Doer {
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		commandKey := hc.commandKey(req)
+		req = req.WithContext(withCommandKey(req.Context(), commandKey))
+		tracker := hc.circuitTracker(commandKey)
+		hc.observeCircuit(commandKey)
+		resolveHedgeCommandKey(req.Context(), commandKey)
+
+		// hystrix may give up on the attempt, e.g. on timeout, while it is still running,
+		// response arriving after that is discarded
+		mu := &sync.Mutex{}
+		isFinished := false
+		var response *http.Response
+
+		var errFallback, errCause error
+		err := hystrix.DoC(req.Context(), commandKey, func(ctx context.Context) error {
+			// an attempt admitted by a non-closed circuit is hystrix's single test request
+			if tracker.beginTest(time.Now()) {
+				defer tracker.endTest()
+			}
+
+			res, errResponse := next.Do(req)
+
+			// losing hedge is reported as cancelled, not counting as circuit's failure
+			if errResponse != nil && isLostHedge(req.Context()) {
+				errResponse = context.Canceled
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if isFinished {
+				if res != nil {
+					res.Body.Close()
+				}
+				return errResponse
+			}
+			response = res
+			return errResponse
+		}, func(ctx context.Context, e error) error {
+			errCause = wrapCircuitError(commandKey, e)
+
+			// losing hedge's outcome is discarded anyway
+			if isLostHedge(ctx) {
+				errFallback = errCause
+				return errFallback
+			}
+
+			switch {
+			case errors.Is(e, hystrix.ErrCircuitOpen):
+				hc.config.MetricsCollector.IncShortCircuit(hc.metricLabels(req))
+				hc.emit(Event{Type: EventReject, CommandKey: commandKey, Reason: e.Error(), Err: errCause})
+			case errors.Is(e, hystrix.ErrMaxConcurrency):
+				hc.emit(Event{Type: EventReject, CommandKey: commandKey, Reason: e.Error(), Err: errCause})
+			case errors.Is(e, hystrix.ErrTimeout):
+				hc.config.MetricsCollector.IncTimeout(hc.metricLabels(req))
+			}
+
+			// degraded response is served by responseFallbackMiddleware, once retries are exhausted
+			if hc.config.CbConfig.ResponseFallback != nil {
+				errFallback = errCause
+				return errFallback
+			}
 
+			// im defining a return here for readability
+			errFallback = hc.config.CbConfig.Fallback(ctx, errCause)
+			return errFallback
+		})
+
+		mu.Lock()
+		isFinished = true
+		res := response
+		mu.Unlock()
+
+		hc.observeCircuit(commandKey)
+
+		if errCause != nil && errFallback != errCause {
+			hc.config.MetricsCollector.IncFallback(hc.metricLabels(req))
+			hc.emit(Event{Type: EventFallback, CommandKey: commandKey, Reason: errCause.Error(), Err: errCause})
+		}
+
+		// hystrix flattens fallback's error into a plain string,
+		// returning fallback's error as is so callers can still inspect it
+		if err != nil && errFallback != nil {
+			err = errFallback
+			if errFallback != errCause {
+				err = &FallbackError{Err: errFallback, Cause: errCause}
+			}
+		}
+
+		return res, err
+	})
 }
 
-// doActual is an in-house "native" httpclient which executes an http request to designated url/host
-// wrapped with circuit breaker functionality
-func (hc *Client) doActual(req *http.Request) (*http.Response, error) {
-	// executes without circuit breaker
-	if !hc.config.IsUsingCircuitBreaker {
-		return hc.client.Do(req)
+// send executes an http request and classifies its response
+func (hc *Client) send(req *http.Request) (*http.Response, error) {
+	req, timer := withAttemptTimer(req)
+	timer.begin()
+	res, err := hc.client.Do(req)
+	timer.end()
+
+	timing := timer.timing()
+	labels := hc.metricLabels(req)
+	labels.StatusClass = statusClass(res)
+	hc.config.MetricsCollector.ObserveRequest(labels, timing.Total)
+	if observer, ok := hc.config.MetricsCollector.(TimingObserver); ok {
+		observer.ObserveTiming(labels, timing)
 	}
 
-	// executes with circuit breaker
-	var response *http.Response
-	err := hystrix.DoC(req.Context(), req.URL.String(), func(ctx context.Context) error {
-		var errResponse error
-		response, errResponse = hc.client.Do(req)
-		return errResponse
-	}, func(ctx context.Context, e error) error {
+	if err != nil {
+		return nil, err
+	}
 
-		// im defining a return here for readability
-		return hc.config.CbConfig.Fallback(ctx, e)
-	})
+	if hc.config.ResponseClassifier(res) {
+		return res, newHTTPError(res)
+	}
 
-	return response, err
+	return res, nil
 }
@@ -1,14 +1,19 @@
 package httpclient
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/afex/hystrix-go/hystrix"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -17,6 +22,16 @@ const (
 	testHost = "http://some-host"
 )
 
+// commandSeq makes command names unique across repeated test runs
+var commandSeq int64
+
+// this is just a helper, hystrix keeps circuits in globals,
+// so every test gets circuit of its own and flushes it afterwards
+func createCommandName(t *testing.T) string {
+	t.Cleanup(hystrix.Flush)
+	return fmt.Sprintf("%s-%d", t.Name(), atomic.AddInt64(&commandSeq, 1))
+}
+
 // this is just a helper
 func createTestServer() *httptest.Server {
 	dummyHandler := func(w http.ResponseWriter, r *http.Request) {
@@ -28,6 +43,59 @@ func createTestServer() *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(dummyHandler))
 }
 
+// testServerConfig tells how the server of createConfiguredTestServer responds
+type testServerConfig struct {
+	status   int                   // response status, default 200
+	failures int                   // how many of the first requests are responded with 503 instead
+	header   http.Header           // response headers
+	body     string                // response body
+	echo     bool                  // responds with request's body and content type instead of body
+	delay    time.Duration         // wait before responding
+	hits     *int32                // counts received requests
+	record   func(r *http.Request) // called on every request before responding, its body is still readable
+}
+
+// this is just a helper, server behaving as configured
+func createConfiguredTestServer(config testServerConfig) *httptest.Server {
+	var requests int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := atomic.AddInt32(&requests, 1)
+		if config.hits != nil {
+			atomic.AddInt32(config.hits, 1)
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		if config.record != nil {
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			config.record(r)
+		}
+
+		time.Sleep(config.delay)
+
+		for key, values := range config.header {
+			w.Header()[key] = values
+		}
+		if config.echo {
+			w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		}
+
+		status := config.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if int(request) <= config.failures {
+			status = http.StatusServiceUnavailable
+		}
+		w.WriteHeader(status)
+
+		if config.echo {
+			w.Write(body)
+			return
+		}
+		w.Write([]byte(config.body))
+	}))
+}
+
 // this is just a helper
 func createTestClient(host string) HttpClient {
 	return NewHttpClient(Config{
@@ -281,3 +349,40 @@ func Test_Do_Failed_OnPreparingRequest(t *testing.T) {
 	_, err := client.Do("Î", parameter)
 	require.Error(t, err)
 }
+
+func Test_Get_MultiValue_QueryAndHeader(t *testing.T) {
+	var rawQuery string
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawQuery = r.URL.RawQuery
+		header = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	client := NewHttpClient(Config{Host: server.URL})
+
+	response, err := client.Get(Parameter{
+		Path: "/ping?b=0",
+		QueryParams: map[string]string{
+			"c": "3",
+		},
+		QueryValues: url.Values{
+			"id": {"2", "1"},
+			"a":  {"x"},
+		},
+		Header: map[string]string{
+			"x-some-header": "some-header-value",
+		},
+		HeaderValues: http.Header{
+			"Accept":        {"application/json", "application/xml"},
+			"X-Some-Header": {"another-header-value"},
+		},
+	})
+	require.NoError(t, err)
+	response.Body.Close()
+
+	// sorted by key, values of the same key keep their order
+	assert.Equal(t, "a=x&b=0&c=3&id=2&id=1", rawQuery)
+	assert.Equal(t, []string{"application/json", "application/xml"}, header.Values("Accept"))
+	assert.Equal(t, []string{"some-header-value", "another-header-value"}, header.Values("X-Some-Header"))
+}
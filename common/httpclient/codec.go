@@ -0,0 +1,271 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Codec encodes Parameter.Body into request body and decodes response body,
+// selected by content type
+type Codec interface {
+	// ContentType returns the media type handled by the codec, e.g. "application/json"
+	ContentType() string
+
+	// Encode converts body object to io.Reader
+	Encode(v interface{}) (io.Reader, error)
+
+	// Decode reads r into v
+	Decode(r io.Reader, v interface{}) error
+}
+
+// supported content types of built-in codecs
+const (
+	ContentTypeJSON        = "application/json"
+	ContentTypeForm        = "application/x-www-form-urlencoded"
+	ContentTypeXML         = "application/xml"
+	ContentTypeMsgpack     = "application/msgpack"
+	ContentTypeOctetStream = "application/octet-stream"
+)
+
+// codec registry, keyed by media type
+var (
+	codecsMutex = &sync.RWMutex{}
+	codecs      = map[string]Codec{}
+)
+
+func init() {
+	RegisterCodec(JSONCodec{})
+	RegisterCodec(FormCodec{})
+	RegisterCodec(XMLCodec{})
+	RegisterCodec(MsgpackCodec{})
+	RegisterCodec(RawCodec{})
+}
+
+// RegisterCodec registers a codec for its content type,
+// replacing the registered one if any
+func RegisterCodec(c Codec) {
+	codecsMutex.Lock()
+	defer codecsMutex.Unlock()
+
+	codecs[strings.ToLower(c.ContentType())] = c
+}
+
+// CodecFor returns the registered codec of a content type,
+// parameters such as charset are ignored and structured syntax suffix e.g. "+json" is supported
+func CodecFor(contentType string) (Codec, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("httpclient: invalid content type %q: %w", contentType, err)
+	}
+
+	codecsMutex.RLock()
+	defer codecsMutex.RUnlock()
+
+	if c, ok := codecs[mediaType]; ok {
+		return c, nil
+	}
+
+	// e.g. "application/problem+json" falls back to "application/json"
+	if i := strings.LastIndex(mediaType, "+"); i >= 0 {
+		if c, ok := codecs["application/"+mediaType[i+1:]]; ok {
+			return c, nil
+		}
+	}
+
+	return nil, fmt.Errorf("httpclient: no codec registered for content type %q", contentType)
+}
+
+// passthrough is a helper to send already encoded body as is
+func passthrough(v interface{}) (io.Reader, bool) {
+	switch b := v.(type) {
+	case io.Reader:
+		return b, true
+	case []byte:
+		return bytes.NewReader(b), true
+	}
+	return nil, false
+}
+
+// JSONCodec encodes and decodes "application/json"
+type JSONCodec struct{}
+
+// ContentType implements Codec
+func (JSONCodec) ContentType() string { return ContentTypeJSON }
+
+// Encode implements Codec
+func (JSONCodec) Encode(v interface{}) (io.Reader, error) {
+	if r, ok := passthrough(v); ok {
+		return r, nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewBuffer(b), nil
+}
+
+// Decode implements Codec
+func (JSONCodec) Decode(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+// FormCodec encodes and decodes "application/x-www-form-urlencoded",
+// supporting url.Values, map[string]string and map[string][]string
+type FormCodec struct{}
+
+// ContentType implements Codec
+func (FormCodec) ContentType() string { return ContentTypeForm }
+
+// Encode implements Codec
+func (FormCodec) Encode(v interface{}) (io.Reader, error) {
+	if r, ok := passthrough(v); ok {
+		return r, nil
+	}
+
+	var values url.Values
+	switch form := v.(type) {
+	case url.Values:
+		values = form
+	case map[string][]string:
+		values = form
+	case map[string]string:
+		values = make(url.Values, len(form))
+		for key, value := range form {
+			values.Set(key, value)
+		}
+	default:
+		return nil, fmt.Errorf("httpclient: form codec does not support %T", v)
+	}
+
+	return strings.NewReader(values.Encode()), nil
+}
+
+// Decode implements Codec
+func (FormCodec) Decode(r io.Reader, v interface{}) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	values, err := url.ParseQuery(string(b))
+	if err != nil {
+		return err
+	}
+
+	switch form := v.(type) {
+	case *url.Values:
+		*form = values
+	case *map[string][]string:
+		*form = values
+	case *map[string]string:
+		*form = make(map[string]string, len(values))
+		for key := range values {
+			(*form)[key] = values.Get(key)
+		}
+	default:
+		return fmt.Errorf("httpclient: form codec does not support %T", v)
+	}
+
+	return nil
+}
+
+// XMLCodec encodes and decodes "application/xml"
+type XMLCodec struct{}
+
+// ContentType implements Codec
+func (XMLCodec) ContentType() string { return ContentTypeXML }
+
+// Encode implements Codec
+func (XMLCodec) Encode(v interface{}) (io.Reader, error) {
+	if r, ok := passthrough(v); ok {
+		return r, nil
+	}
+
+	b, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewBuffer(b), nil
+}
+
+// Decode implements Codec
+func (XMLCodec) Decode(r io.Reader, v interface{}) error {
+	return xml.NewDecoder(r).Decode(v)
+}
+
+// MsgpackCodec encodes and decodes "application/msgpack"
+type MsgpackCodec struct{}
+
+// msgpackHandle is shared across encoding and decoding, it is safe for concurrent use once configured
+var msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+
+// ContentType implements Codec
+func (MsgpackCodec) ContentType() string { return ContentTypeMsgpack }
+
+// Encode implements Codec
+func (MsgpackCodec) Encode(v interface{}) (io.Reader, error) {
+	if r, ok := passthrough(v); ok {
+		return r, nil
+	}
+
+	var b []byte
+	if err := codec.NewEncoderBytes(&b, msgpackHandle).Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.NewBuffer(b), nil
+}
+
+// Decode implements Codec
+func (MsgpackCodec) Decode(r io.Reader, v interface{}) error {
+	return codec.NewDecoder(r, msgpackHandle).Decode(v)
+}
+
+// RawCodec sends and receives "application/octet-stream" as is,
+// supporting []byte, string and io.Reader
+type RawCodec struct{}
+
+// ContentType implements Codec
+func (RawCodec) ContentType() string { return ContentTypeOctetStream }
+
+// Encode implements Codec
+func (RawCodec) Encode(v interface{}) (io.Reader, error) {
+	if r, ok := passthrough(v); ok {
+		return r, nil
+	}
+
+	if s, ok := v.(string); ok {
+		return strings.NewReader(s), nil
+	}
+
+	return nil, fmt.Errorf("httpclient: raw codec does not support %T", v)
+}
+
+// Decode implements Codec
+func (RawCodec) Decode(r io.Reader, v interface{}) error {
+	switch out := v.(type) {
+	case io.Writer:
+		_, err := io.Copy(out, r)
+		return err
+	case *[]byte:
+		b, err := ioutil.ReadAll(r)
+		*out = b
+		return err
+	case *string:
+		b, err := ioutil.ReadAll(r)
+		*out = string(b)
+		return err
+	}
+
+	return fmt.Errorf("httpclient: raw codec does not support %T", v)
+}
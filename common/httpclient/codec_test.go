@@ -0,0 +1,177 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// this is just a helper
+func encodeToString(t *testing.T, c Codec, v interface{}) string {
+	r, err := c.Encode(v)
+	require.NoError(t, err)
+	b, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func Test_CodecFor(t *testing.T) {
+	testCases := map[string]string{
+		"application/json":                  ContentTypeJSON,
+		"application/json; charset=utf-8":   ContentTypeJSON,
+		"application/problem+json":          ContentTypeJSON,
+		"application/x-www-form-urlencoded": ContentTypeForm,
+		"application/xml":                   ContentTypeXML,
+		"application/msgpack":               ContentTypeMsgpack,
+		"application/octet-stream":          ContentTypeOctetStream,
+	}
+
+	for contentType, expected := range testCases {
+		c, err := CodecFor(contentType)
+		require.NoError(t, err, contentType)
+		assert.Equal(t, expected, c.ContentType(), contentType)
+	}
+}
+
+func Test_CodecFor_Unknown(t *testing.T) {
+	_, err := CodecFor("text/some-unknown")
+	assert.Error(t, err)
+
+	_, err = CodecFor("")
+	assert.Error(t, err)
+}
+
+func Test_JSONCodec(t *testing.T) {
+	assert.Equal(t, `{"someKey":"someValue"}`, encodeToString(t, JSONCodec{}, map[string]string{"someKey": "someValue"}))
+
+	var out map[string]string
+	require.NoError(t, JSONCodec{}.Decode(strings.NewReader(`{"someKey":"someValue"}`), &out))
+	assert.Equal(t, map[string]string{"someKey": "someValue"}, out)
+}
+
+func Test_FormCodec(t *testing.T) {
+	assert.Equal(t, "a=1&b=2", encodeToString(t, FormCodec{}, map[string]string{"a": "1", "b": "2"}))
+	assert.Equal(t, "id=1&id=2", encodeToString(t, FormCodec{}, url.Values{"id": {"1", "2"}}))
+
+	_, err := FormCodec{}.Encode(struct{}{})
+	assert.Error(t, err)
+
+	var out url.Values
+	require.NoError(t, FormCodec{}.Decode(strings.NewReader("id=1&id=2"), &out))
+	assert.Equal(t, url.Values{"id": {"1", "2"}}, out)
+}
+
+func Test_XMLCodec(t *testing.T) {
+	type Body struct {
+		XMLName xml.Name `xml:"body"`
+		SomeKey string   `xml:"someKey"`
+	}
+
+	assert.Equal(t, `<body><someKey>someValue</someKey></body>`, encodeToString(t, XMLCodec{}, Body{SomeKey: "someValue"}))
+
+	var out Body
+	require.NoError(t, XMLCodec{}.Decode(strings.NewReader(`<body><someKey>someValue</someKey></body>`), &out))
+	assert.Equal(t, "someValue", out.SomeKey)
+}
+
+func Test_MsgpackCodec(t *testing.T) {
+	in := map[string]string{"someKey": "someValue"}
+
+	r, err := MsgpackCodec{}.Encode(in)
+	require.NoError(t, err)
+
+	var out map[string]string
+	require.NoError(t, MsgpackCodec{}.Decode(r, &out))
+	assert.Equal(t, in, out)
+}
+
+func Test_RawCodec(t *testing.T) {
+	assert.Equal(t, "some-bytes", encodeToString(t, RawCodec{}, []byte("some-bytes")))
+	assert.Equal(t, "some-string", encodeToString(t, RawCodec{}, "some-string"))
+	assert.Equal(t, "some-reader", encodeToString(t, RawCodec{}, strings.NewReader("some-reader")))
+
+	_, err := RawCodec{}.Encode(1)
+	assert.Error(t, err)
+
+	var out []byte
+	require.NoError(t, RawCodec{}.Decode(strings.NewReader("some-bytes"), &out))
+	assert.Equal(t, []byte("some-bytes"), out)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, RawCodec{}.Decode(strings.NewReader("some-bytes"), buf))
+	assert.Equal(t, "some-bytes", buf.String())
+}
+
+func Test_Codec_Passthrough(t *testing.T) {
+	for _, c := range []Codec{JSONCodec{}, FormCodec{}, XMLCodec{}, MsgpackCodec{}} {
+		assert.Equal(t, "already-encoded", encodeToString(t, c, []byte("already-encoded")), c.ContentType())
+		assert.Equal(t, "already-encoded", encodeToString(t, c, strings.NewReader("already-encoded")), c.ContentType())
+	}
+}
+
+func Test_Post_ContentType(t *testing.T) {
+	server := createConfiguredTestServer(testServerConfig{echo: true})
+	defer server.Close()
+
+	testCases := []struct {
+		name                string
+		clientContentType   string
+		param               Parameter
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "default json",
+			param:               Parameter{Body: map[string]string{"a": "1"}},
+			expectedContentType: ContentTypeJSON,
+			expectedBody:        `{"a":"1"}`,
+		},
+		{
+			name:                "per client form",
+			clientContentType:   ContentTypeForm,
+			param:               Parameter{Body: map[string]string{"a": "1"}},
+			expectedContentType: ContentTypeForm,
+			expectedBody:        `a=1`,
+		},
+		{
+			name:                "per parameter raw",
+			clientContentType:   ContentTypeForm,
+			param:               Parameter{Body: []byte("raw"), ContentType: ContentTypeOctetStream},
+			expectedContentType: ContentTypeOctetStream,
+			expectedBody:        `raw`,
+		},
+		{
+			name: "explicit header",
+			param: Parameter{
+				Body:   strings.NewReader(`{"a":"1"}`),
+				Header: map[string]string{"Content-Type": "application/vnd.some+json"},
+			},
+			expectedContentType: "application/vnd.some+json",
+			expectedBody:        `{"a":"1"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewHttpClient(Config{
+				Host:        server.URL,
+				ContentType: tc.clientContentType,
+			})
+
+			response, err := client.Post(tc.param)
+			require.NoError(t, err)
+			defer response.Body.Close()
+
+			body, err := ioutil.ReadAll(response.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedContentType, response.Header.Get("Content-Type"))
+			assert.Equal(t, tc.expectedBody, string(body))
+		})
+	}
+}
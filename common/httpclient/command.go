@@ -0,0 +1,133 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/afex/hystrix-go/hystrix"
+)
+
+// CommandNamer resolves the circuit breaker command key of a request.
+// requests sharing the same key share the same circuit
+type CommandNamer func(req *http.Request) string
+
+// CommandNamerByHost groups circuits per scheme + host,
+// e.g. "http://localhost:3002"
+func CommandNamerByHost() CommandNamer {
+	return func(req *http.Request) string {
+		return fmt.Sprintf("%s://%s", req.URL.Scheme, req.URL.Host)
+	}
+}
+
+// CommandNamerByRoute groups circuits per http method + route template,
+// e.g. "POST http://localhost:3002/users/{id}".
+// route template is taken from Parameter.Path, when request is not executed via DoContext
+// it falls back to the request's url path
+func CommandNamerByRoute() CommandNamer {
+	return func(req *http.Request) string {
+		route, ok := RouteTemplate(req.Context())
+		if !ok {
+			route = req.URL.Path
+		}
+		return fmt.Sprintf("%s %s://%s%s", req.Method, req.URL.Scheme, req.URL.Host, route)
+	}
+}
+
+// CommandNamerByName puts every request into a single circuit with explicit name
+func CommandNamerByName(name string) CommandNamer {
+	return func(req *http.Request) string {
+		return name
+	}
+}
+
+// routeTemplateKey is the context key to carry route template from DoContext to CommandNamer
+type routeTemplateKey struct{}
+
+// withRouteTemplate is a helper to attach route template into context
+func withRouteTemplate(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeTemplateKey{}, route)
+}
+
+// RouteTemplate returns the route template, i.e. Parameter.Path before its variables are resolved,
+// of a request executed via DoContext. it is a low-cardinality label for circuits and metrics
+func RouteTemplate(ctx context.Context) (string, bool) {
+	route, ok := ctx.Value(routeTemplateKey{}).(string)
+	return route, ok
+}
+
+// commandKeyKey is the context key to carry command key from circuit breaker to attempt middlewares
+type commandKeyKey struct{}
+
+// withCommandKey is a helper to attach command key into context
+func withCommandKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, commandKeyKey{}, key)
+}
+
+// CommandKey returns circuit breaker command key of an attempt,
+// available to Config.AttemptMiddlewares when circuit breaker is on
+func CommandKey(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(commandKeyKey{}).(string)
+	return key, ok
+}
+
+// commandKey resolves command key of a request
+// and make sure the configured circuit breaker config is applied to it
+func (hc *Client) commandKey(req *http.Request) string {
+	key := hc.config.CommandNamer(req)
+	if _, ok := hc.commands.Load(key); ok {
+		return key
+	}
+
+	// hystrix needs the command to be configured before its first execution,
+	// otherwise the circuit will be running on hystrix defaults
+	if _, loaded := hc.commands.LoadOrStore(key, newCircuitTracker()); !loaded {
+		ownCircuit(key)
+		hystrix.ConfigureCommand(key, hc.commandConfig())
+		hc.registerCircuitCollector(key, req)
+	}
+
+	return key
+}
+
+// commandConfig is a helper to map CircuitBreakerConfig to hystrix.CommandConfig
+func (hc *Client) commandConfig() hystrix.CommandConfig {
+	return hystrix.CommandConfig{
+		Timeout:                hc.config.CbConfig.Timeout,
+		SleepWindow:            hc.config.CbConfig.SleepWindow,
+		RequestVolumeThreshold: hc.config.CbConfig.RequestVolumeThreshold,
+		ErrorPercentThreshold:  hc.config.CbConfig.ErrorPercentThreshold,
+		MaxConcurrentRequests:  hc.config.CbConfig.MaxConcurrentRequests,
+	}
+}
+
+// registerCircuitCollector attaches collector created by Config.CircuitCollector to a circuit.
+// circuits are shared by clients of the same command key, the latest client registering it wins,
+// the collector it replaces is closed when it is an io.Closer
+func (hc *Client) registerCircuitCollector(key string, req *http.Request) {
+	if hc.config.CircuitCollector == nil {
+		return
+	}
+
+	// route only labels circuits which are per route
+	route, ok := RouteTemplate(req.Context())
+	if !ok || key != CommandNamerByRoute()(req) {
+		route = ""
+	}
+
+	sink := hc.config.CircuitCollector(CircuitInfo{
+		CommandKey: key,
+		Client:     hc.config.Name,
+		Route:      route,
+	})
+
+	circuitSinksMutex.Lock()
+	previous, loaded := circuitSinks.Load(key)
+	circuitSinks.Store(key, sink)
+	circuitSinksMutex.Unlock()
+
+	if closer, ok := previous.(io.Closer); loaded && ok {
+		closer.Close()
+	}
+}
@@ -0,0 +1,169 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// this is just a helper
+func createTestRequest(t *testing.T, ctx context.Context, method, url string) *http.Request {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	require.NoError(t, err)
+	return req
+}
+
+func Test_CommandNamerByHost(t *testing.T) {
+	req := createTestRequest(t, context.Background(), http.MethodGet, "http://some-host:3000/ping/1?a=b")
+
+	assert.Equal(t, "http://some-host:3000", CommandNamerByHost()(req))
+}
+
+func Test_CommandNamerByRoute(t *testing.T) {
+	ctx := withRouteTemplate(context.Background(), "/ping")
+	req := createTestRequest(t, ctx, http.MethodPost, "http://some-host/ping/1?a=b")
+
+	assert.Equal(t, "POST http://some-host/ping", CommandNamerByRoute()(req))
+}
+
+func Test_CommandNamerByRoute_WithoutTemplate(t *testing.T) {
+	req := createTestRequest(t, context.Background(), http.MethodGet, "http://some-host/ping/1?a=b")
+
+	assert.Equal(t, "GET http://some-host/ping/1", CommandNamerByRoute()(req))
+}
+
+func Test_CommandNamerByName(t *testing.T) {
+	req := createTestRequest(t, context.Background(), http.MethodGet, "http://some-host/ping")
+
+	assert.Equal(t, "some-name", CommandNamerByName("some-name")(req))
+}
+
+func Test_CommandKey_AppliesConfig(t *testing.T) {
+	commandName := createCommandName(t)
+	client := NewHttpClient(Config{
+		Host:                  testHost,
+		IsUsingCircuitBreaker: true,
+		CommandNamer:          CommandNamerByName(commandName),
+		CbConfig: CircuitBreakerConfig{
+			SleepWindow:            1234,
+			RequestVolumeThreshold: 7,
+			Timeout:                4321,
+		},
+	}).(*Client)
+
+	req := createTestRequest(t, context.Background(), http.MethodGet, testHost+"/ping?a=b")
+	key := client.commandKey(req)
+	require.Equal(t, commandName, key)
+
+	settings := hystrix.GetCircuitSettings()[key]
+	require.NotNil(t, settings)
+	assert.Equal(t, 1234*time.Millisecond, settings.SleepWindow)
+	assert.Equal(t, uint64(7), settings.RequestVolumeThreshold)
+	assert.Equal(t, 4321*time.Millisecond, settings.Timeout)
+}
+
+func Test_Circuit_OpensAfterThreshold(t *testing.T) {
+	// closed server, every request will fail on dialing
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	commandName := createCommandName(t)
+	client := NewHttpClient(Config{
+		Host:                  server.URL,
+		IsUsingCircuitBreaker: true,
+		CommandNamer:          CommandNamerByName(commandName),
+		CbConfig: CircuitBreakerConfig{
+			SleepWindow:            60000,
+			RequestVolumeThreshold: 3,
+		},
+	})
+
+	// distinct urls should still land on the same circuit
+	for i := 0; i < 3; i++ {
+		_, err := client.Get(Parameter{
+			Path:        "/ping",
+			QueryParams: map[string]string{"attempt": strconv.Itoa(i)},
+		})
+		require.Error(t, err)
+	}
+
+	circuit, _, err := hystrix.GetCircuit(commandName)
+	require.NoError(t, err)
+
+	// metrics are collected asynchronously by hystrix
+	require.Eventually(t, circuit.IsOpen, time.Second, 10*time.Millisecond)
+
+	_, err = client.Get(Parameter{Path: "/ping"})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+}
+
+func Test_Circuit_StaysClosedBelowThreshold(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	commandName := createCommandName(t)
+	client := NewHttpClient(Config{
+		Host:                  server.URL,
+		IsUsingCircuitBreaker: true,
+		CommandNamer:          CommandNamerByName(commandName),
+		CbConfig: CircuitBreakerConfig{
+			RequestVolumeThreshold: 5,
+		},
+	})
+
+	for i := 0; i < 4; i++ {
+		_, err := client.Get(Parameter{Path: "/ping"})
+		require.Error(t, err)
+	}
+
+	circuit, _, err := hystrix.GetCircuit(commandName)
+	require.NoError(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, circuit.IsOpen())
+}
+
+func Test_CommandKey_AvailableToAttemptMiddlewares(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var callKey, attemptKey string
+	var callOk, attemptOk bool
+	commandName := createCommandName(t)
+	client := NewHttpClient(Config{
+		Host:                  server.URL,
+		IsUsingCircuitBreaker: true,
+		CommandNamer:          CommandNamerByName(commandName),
+		CallMiddlewares: []Middleware{func(next Doer) Doer {
+			return DoerFunc(func(req *http.Request) (*http.Response, error) {
+				callKey, callOk = CommandKey(req.Context())
+				return next.Do(req)
+			})
+		}},
+		AttemptMiddlewares: []Middleware{func(next Doer) Doer {
+			return DoerFunc(func(req *http.Request) (*http.Response, error) {
+				attemptKey, attemptOk = CommandKey(req.Context())
+				return next.Do(req)
+			})
+		}},
+	})
+
+	response, err := client.Get(Parameter{Path: "/ping"})
+	require.NoError(t, err)
+	response.Body.Close()
+
+	assert.False(t, callOk)
+	assert.Empty(t, callKey)
+	assert.True(t, attemptOk)
+	assert.Equal(t, commandName, attemptKey)
+}
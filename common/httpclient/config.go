@@ -0,0 +1,138 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// validate is a helper to check Config before defaults are applied
+func (c Config) validate() error {
+	if c.Host != "" {
+		if _, err := url.Parse(c.Host); err != nil {
+			return &ConfigError{Field: "Host", Reason: err.Error()}
+		}
+	}
+
+	if c.Timeout < 0 {
+		return &ConfigError{Field: "Timeout", Reason: "must not be negative"}
+	}
+
+	if c.RetryCount < 0 {
+		return &ConfigError{Field: "RetryCount", Reason: "must not be negative"}
+	}
+
+	if c.ContentType != "" {
+		if _, err := CodecFor(c.ContentType); err != nil {
+			return &ConfigError{Field: "ContentType", Reason: err.Error()}
+		}
+	}
+
+	// response fallback is served by circuit breaker
+	if c.CbConfig.ResponseFallback != nil && !c.IsUsingCircuitBreaker {
+		return &ConfigError{Field: "CbConfig.ResponseFallback", Reason: "requires IsUsingCircuitBreaker"}
+	}
+
+	if c.Hedging != nil {
+		if err := c.Hedging.validate(); err != nil {
+			return err
+		}
+	}
+
+	return c.CbConfig.validate()
+}
+
+// validate is a helper to check HedgingConfig before defaults are applied
+func (c HedgingConfig) validate() error {
+	if c.Delay < 0 {
+		return &ConfigError{Field: "Hedging.Delay", Reason: "must not be negative"}
+	}
+
+	if c.Percentile < 0 || c.Percentile > 1 {
+		return &ConfigError{Field: "Hedging.Percentile", Reason: "must be within 0-1"}
+	}
+
+	// hedging more than once per request would more than double the load
+	if c.MaxRatio < 0 || c.MaxRatio > 1 {
+		return &ConfigError{Field: "Hedging.MaxRatio", Reason: "must be within 0-1"}
+	}
+
+	return nil
+}
+
+// validate is a helper to check CircuitBreakerConfig before defaults are applied,
+// zero value means hystrix default
+func (c CircuitBreakerConfig) validate() error {
+	nonNegatives := []struct {
+		field string
+		value int
+	}{
+		{"CbConfig.SleepWindow", c.SleepWindow},
+		{"CbConfig.RequestVolumeThreshold", c.RequestVolumeThreshold},
+		{"CbConfig.ErrorThreshold", c.ErrorThreshold},
+		{"CbConfig.MaxConcurrentRequests", c.MaxConcurrentRequests},
+		{"CbConfig.Timeout", c.Timeout},
+	}
+	for _, v := range nonNegatives {
+		if v.value < 0 {
+			return &ConfigError{Field: v.field, Reason: "must not be negative"}
+		}
+	}
+
+	if c.ErrorPercentThreshold < 0 || c.ErrorPercentThreshold > 100 {
+		return &ConfigError{Field: "CbConfig.ErrorPercentThreshold", Reason: "must be within 1-100, or zero for hystrix default"}
+	}
+
+	// deprecated alias must agree with its replacement
+	if c.ErrorThreshold != 0 && c.RequestVolumeThreshold != 0 && c.ErrorThreshold != c.RequestVolumeThreshold {
+		return &ConfigError{
+			Field:  "CbConfig.ErrorThreshold",
+			Reason: fmt.Sprintf("conflicts with RequestVolumeThreshold (%d != %d), use RequestVolumeThreshold only", c.ErrorThreshold, c.RequestVolumeThreshold),
+		}
+	}
+
+	return nil
+}
+
+// withDefault is a helper to drop an invalid field of Config, so its default is applied instead,
+// it reports false on a field it does not know
+func (c Config) withDefault(field string) (Config, bool) {
+	switch field {
+	case "Host":
+		c.Host = ""
+	case "Timeout":
+		c.Timeout = 0
+	case "RetryCount":
+		c.RetryCount = 0
+	case "ContentType":
+		c.ContentType = ""
+	case "CbConfig.ResponseFallback":
+		c.CbConfig.ResponseFallback = nil
+	case "Hedging.Delay":
+		hedging := *c.Hedging
+		hedging.Delay = 0
+		c.Hedging = &hedging
+	case "Hedging.Percentile":
+		hedging := *c.Hedging
+		hedging.Percentile = 0
+		c.Hedging = &hedging
+	case "Hedging.MaxRatio":
+		hedging := *c.Hedging
+		hedging.MaxRatio = 0
+		c.Hedging = &hedging
+	case "CbConfig.SleepWindow":
+		c.CbConfig.SleepWindow = 0
+	case "CbConfig.RequestVolumeThreshold":
+		c.CbConfig.RequestVolumeThreshold = 0
+	case "CbConfig.ErrorThreshold":
+		c.CbConfig.ErrorThreshold = 0
+	case "CbConfig.MaxConcurrentRequests":
+		c.CbConfig.MaxConcurrentRequests = 0
+	case "CbConfig.Timeout":
+		c.CbConfig.Timeout = 0
+	case "CbConfig.ErrorPercentThreshold":
+		c.CbConfig.ErrorPercentThreshold = 0
+	default:
+		return c, false
+	}
+	return c, true
+}
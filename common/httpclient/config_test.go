@@ -0,0 +1,206 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewHttpClientE_Invalid(t *testing.T) {
+	testCases := map[string]Config{
+		"CbConfig.SleepWindow":            {CbConfig: CircuitBreakerConfig{SleepWindow: -1}},
+		"CbConfig.RequestVolumeThreshold": {CbConfig: CircuitBreakerConfig{RequestVolumeThreshold: -1}},
+		"CbConfig.MaxConcurrentRequests":  {CbConfig: CircuitBreakerConfig{MaxConcurrentRequests: -1}},
+		"CbConfig.Timeout":                {CbConfig: CircuitBreakerConfig{Timeout: -1}},
+		"CbConfig.ErrorPercentThreshold":  {CbConfig: CircuitBreakerConfig{ErrorPercentThreshold: 101}},
+		"CbConfig.ErrorThreshold":         {CbConfig: CircuitBreakerConfig{ErrorThreshold: 10, RequestVolumeThreshold: 20}},
+		"Timeout":                         {Timeout: -time.Second},
+		"RetryCount":                      {RetryCount: -1},
+		"ContentType":                     {ContentType: "text/some-unknown"},
+		"Hedging.Delay":                   {Hedging: &HedgingConfig{Delay: -time.Millisecond}},
+		"Hedging.Percentile":              {Hedging: &HedgingConfig{Percentile: 95}},
+		"Hedging.MaxRatio":                {Hedging: &HedgingConfig{MaxRatio: 2}},
+	}
+
+	for field, config := range testCases {
+		config.Host = testHost
+		config.IsUsingCircuitBreaker = true
+
+		client, err := NewHttpClientE(config)
+		assert.Nil(t, client, field)
+
+		var configErr *ConfigError
+		require.True(t, errors.As(err, &configErr), field)
+		assert.Equal(t, field, configErr.Field)
+
+		// lenient constructor falls back to defaults
+		config.Logger = NopLogger{}
+		assert.NotNil(t, NewHttpClient(config), field)
+	}
+}
+
+func Test_NewHttpClientE_ResponseFallbackWithoutCircuitBreaker(t *testing.T) {
+	_, err := NewHttpClientE(Config{
+		Host: testHost,
+		CbConfig: CircuitBreakerConfig{
+			ResponseFallback: StaticFallback(http.StatusOK, nil, nil),
+		},
+	})
+
+	var configErr *ConfigError
+	require.True(t, errors.As(err, &configErr))
+	assert.Equal(t, "CbConfig.ResponseFallback", configErr.Field)
+}
+
+func Test_Config_WithDefault_EveryField(t *testing.T) {
+	// every field validate may report, read from its source so new ones are not missed
+	source, err := ioutil.ReadFile("config.go")
+	require.NoError(t, err)
+	fields := map[string]bool{}
+	for _, match := range regexp.MustCompile(`(?:Field:\s*|\{)"([A-Za-z.]+)"`).FindAllStringSubmatch(string(source), -1) {
+		fields[match[1]] = true
+	}
+	require.NotEmpty(t, fields)
+
+	config := Config{
+		Host:        "http://[::1",
+		Timeout:     -time.Second,
+		RetryCount:  -1,
+		ContentType: "text/some-unknown",
+		Hedging:     &HedgingConfig{Delay: -time.Millisecond, Percentile: 95, MaxRatio: 2},
+		CbConfig: CircuitBreakerConfig{
+			SleepWindow:            -1,
+			RequestVolumeThreshold: -1,
+			ErrorThreshold:         -1,
+			MaxConcurrentRequests:  -1,
+			Timeout:                -1,
+			ErrorPercentThreshold:  101,
+			ResponseFallback:       StaticFallback(http.StatusOK, nil, nil),
+		},
+	}
+	for field := range fields {
+		defaulted, ok := config.withDefault(field)
+		assert.True(t, ok, field)
+		assert.NotEqual(t, fmt.Sprintf("%+v", config), fmt.Sprintf("%+v", defaulted), field)
+	}
+
+	_, ok := config.withDefault("SomeUnknownField")
+	assert.False(t, ok)
+}
+
+func Test_NewHttpClient_DefaultsOnInvalid(t *testing.T) {
+	logger := createRecordingLogger()
+	client := NewHttpClient(Config{
+		Host:       testHost,
+		RetryCount: -1,
+		Timeout:    -time.Second,
+		Logger:     logger,
+		CbConfig: CircuitBreakerConfig{
+			ErrorPercentThreshold: 101,
+			ResponseFallback:      StaticFallback(http.StatusOK, nil, nil),
+		},
+	}).(*Client)
+
+	assert.Equal(t, 0, client.config.RetryCount)
+	assert.Equal(t, defautTimeout, client.config.Timeout)
+	assert.Equal(t, 0, client.config.CbConfig.ErrorPercentThreshold)
+	assert.Nil(t, client.config.CbConfig.ResponseFallback)
+
+	var fields []interface{}
+	for _, entry := range logger.find("httpclient invalid config, using default") {
+		assert.Equal(t, LevelWarn, entry.level)
+		fields = append(fields, entry.keyvals["field"])
+	}
+	assert.ElementsMatch(t, []interface{}{"Timeout", "RetryCount", "CbConfig.ResponseFallback", "CbConfig.ErrorPercentThreshold"}, fields)
+}
+
+func Test_CommandConfig_AllKnobs(t *testing.T) {
+	client, err := NewHttpClientE(Config{
+		Host:                  testHost,
+		IsUsingCircuitBreaker: true,
+		CommandNamer:          CommandNamerByName(createCommandName(t)),
+		CbConfig: CircuitBreakerConfig{
+			SleepWindow:            1000,
+			RequestVolumeThreshold: 3,
+			ErrorPercentThreshold:  25,
+			MaxConcurrentRequests:  2,
+			Timeout:                500,
+		},
+	})
+	require.NoError(t, err)
+
+	req := createTestRequest(t, context.Background(), http.MethodGet, testHost)
+	key := client.(*Client).commandKey(req)
+
+	settings := hystrix.GetCircuitSettings()[key]
+	require.NotNil(t, settings)
+	assert.Equal(t, time.Second, settings.SleepWindow)
+	assert.Equal(t, uint64(3), settings.RequestVolumeThreshold)
+	assert.Equal(t, 25, settings.ErrorPercentThreshold)
+	assert.Equal(t, 2, settings.MaxConcurrentRequests)
+	assert.Equal(t, 500*time.Millisecond, settings.Timeout)
+}
+
+func Test_CommandConfig_DeprecatedErrorThreshold(t *testing.T) {
+	client, err := NewHttpClientE(Config{
+		Host:                  testHost,
+		IsUsingCircuitBreaker: true,
+		CommandNamer:          CommandNamerByName(createCommandName(t)),
+		CbConfig: CircuitBreakerConfig{
+			ErrorThreshold: 7,
+		},
+	})
+	require.NoError(t, err)
+
+	req := createTestRequest(t, context.Background(), http.MethodGet, testHost)
+	key := client.(*Client).commandKey(req)
+	assert.Equal(t, uint64(7), hystrix.GetCircuitSettings()[key].RequestVolumeThreshold)
+}
+
+func Test_MaxConcurrentRequests_Rejects(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+	}))
+	defer server.Close()
+
+	client := NewHttpClient(Config{
+		Host:                  server.URL,
+		IsUsingCircuitBreaker: true,
+		CommandNamer:          CommandNamerByName(createCommandName(t)),
+		CbConfig: CircuitBreakerConfig{
+			MaxConcurrentRequests: 1,
+		},
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		response, err := client.Get(Parameter{Path: "/ping"})
+		if response != nil {
+			response.Body.Close()
+		}
+		done <- err
+	}()
+	<-started
+
+	_, err := client.Get(Parameter{Path: "/ping"})
+	assert.ErrorIs(t, err, ErrMaxConcurrency)
+
+	close(release)
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,84 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// GetInto executes an http request with method GET
+// and decodes the response body into out
+func (hc *Client) GetInto(ctx context.Context, param Parameter, out interface{}) (*http.Response, error) {
+	return hc.DoInto(ctx, http.MethodGet, param, out)
+}
+
+// PostInto executes an http request with method POST
+// and decodes the response body into out
+func (hc *Client) PostInto(ctx context.Context, param Parameter, out interface{}) (*http.Response, error) {
+	return hc.DoInto(ctx, http.MethodPost, param, out)
+}
+
+// PutInto executes an http request with method PUT
+// and decodes the response body into out
+func (hc *Client) PutInto(ctx context.Context, param Parameter, out interface{}) (*http.Response, error) {
+	return hc.DoInto(ctx, http.MethodPut, param, out)
+}
+
+// DeleteInto executes an http request with method DELETE
+// and decodes the response body into out
+func (hc *Client) DeleteInto(ctx context.Context, param Parameter, out interface{}) (*http.Response, error) {
+	return hc.DoInto(ctx, http.MethodDelete, param, out)
+}
+
+// DoInto executes an http request like DoContext and decodes the response body into out
+// using the codec of response's content type.
+// non-2xx response is returned as *HTTPError and decoding failure as *DecodeError.
+// the response body is always closed, the returned response is only meant for its status and headers
+func (hc *Client) DoInto(ctx context.Context, httpMethod string, param Parameter, out interface{}) (*http.Response, error) {
+	res, err := hc.DoContext(ctx, httpMethod, param)
+	if res != nil {
+		defer closeBody(res)
+	}
+	if err != nil {
+		return res, err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res, newHTTPError(res)
+	}
+
+	return res, hc.decodeBody(res, out)
+}
+
+// decodeBody is a helper to decode response body into out
+func (hc *Client) decodeBody(res *http.Response, out interface{}) error {
+	if out == nil || res.StatusCode == http.StatusNoContent || (res.Request != nil && res.Request.Method == http.MethodHead) {
+		return nil
+	}
+
+	contentType := res.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = hc.config.ContentType
+	}
+
+	codec, err := CodecFor(contentType)
+	if err != nil {
+		return &DecodeError{StatusCode: res.StatusCode, ContentType: contentType, Err: err}
+	}
+
+	// empty body leaves out untouched
+	if err := codec.Decode(res.Body, out); err != nil && !errors.Is(err, io.EOF) {
+		return &DecodeError{StatusCode: res.StatusCode, ContentType: contentType, Err: err}
+	}
+
+	return nil
+}
+
+// closeBody is a helper to drain and close response body,
+// draining lets the underlying connection be reused
+func closeBody(res *http.Response) {
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+}
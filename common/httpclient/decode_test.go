@@ -0,0 +1,200 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// trackingBody records whether it has been closed
+type trackingBody struct {
+	io.ReadCloser
+	closed bool
+}
+
+func (b *trackingBody) Close() error {
+	b.closed = true
+	return b.ReadCloser.Close()
+}
+
+// this is just a helper, client whose every response body is tracked
+func createTrackingTestClient(host string, bodies *[]*trackingBody) HttpClient {
+	return NewHttpClient(Config{
+		Host: host,
+		AttemptMiddlewares: []Middleware{
+			func(next Doer) Doer {
+				return DoerFunc(func(req *http.Request) (*http.Response, error) {
+					res, err := next.Do(req)
+					if res != nil {
+						body := &trackingBody{ReadCloser: res.Body}
+						res.Body = body
+						*bodies = append(*bodies, body)
+					}
+					return res, err
+				})
+			},
+		},
+	})
+}
+
+func Test_GetInto_Success(t *testing.T) {
+	server := createConfiguredTestServer(testServerConfig{
+		header: http.Header{"Content-Type": {"application/json; charset=utf-8"}},
+		body:   `{ "response": "ok" }`,
+	})
+	defer server.Close()
+
+	var bodies []*trackingBody
+	client := createTrackingTestClient(server.URL, &bodies)
+
+	var out map[string]string
+	response, err := client.GetInto(context.Background(), Parameter{Path: "/ping"}, &out)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, map[string]string{"response": "ok"}, out)
+
+	require.Len(t, bodies, 1)
+	assert.True(t, bodies[0].closed)
+}
+
+func Test_DoInto_ContentTypeCodec(t *testing.T) {
+	server := createConfiguredTestServer(testServerConfig{
+		header: http.Header{"Content-Type": {ContentTypeForm}},
+		body:   `response=ok`,
+	})
+	defer server.Close()
+
+	client := NewHttpClient(Config{Host: server.URL})
+
+	var out map[string]string
+	_, err := client.DoInto(context.Background(), http.MethodPatch, Parameter{Path: "/ping"}, &out)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"response": "ok"}, out)
+}
+
+func Test_DoInto_ResponseWithoutRequest(t *testing.T) {
+	client := NewHttpClient(Config{
+		Host: testHost,
+		AttemptMiddlewares: []Middleware{func(next Doer) Doer {
+			// synthetic response, e.g. served from a cache, carries no request
+			return DoerFunc(func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": {ContentTypeJSON}},
+					Body:       ioutil.NopCloser(strings.NewReader(`{ "response": "synthetic" }`)),
+				}, nil
+			})
+		}},
+	})
+
+	var out map[string]string
+	_, err := client.GetInto(context.Background(), Parameter{Path: "/ping"}, &out)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"response": "synthetic"}, out)
+}
+
+func Test_DoInto_EmptyBody(t *testing.T) {
+	server := createConfiguredTestServer(testServerConfig{
+		header: http.Header{"Content-Type": {ContentTypeJSON}},
+		body:   ``,
+	})
+	defer server.Close()
+
+	client := NewHttpClient(Config{Host: server.URL})
+
+	var out map[string]string
+	_, err := client.PutInto(context.Background(), Parameter{Path: "/ping"}, &out)
+	require.NoError(t, err)
+	assert.Nil(t, out)
+}
+
+func Test_DoInto_Failed_Status(t *testing.T) {
+	server := createConfiguredTestServer(testServerConfig{
+		status: http.StatusNotFound,
+		header: http.Header{"Content-Type": {ContentTypeJSON}},
+		body:   `{ "error": "not found" }`,
+	})
+	defer server.Close()
+
+	var bodies []*trackingBody
+	client := createTrackingTestClient(server.URL, &bodies)
+
+	var out map[string]string
+	response, err := client.DeleteInto(context.Background(), Parameter{Path: "/ping"}, &out)
+
+	var httpErr *HTTPError
+	require.True(t, errors.As(err, &httpErr))
+	assert.Equal(t, http.StatusNotFound, httpErr.StatusCode())
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	assert.Nil(t, out)
+
+	require.Len(t, bodies, 1)
+	assert.True(t, bodies[0].closed)
+}
+
+func Test_DoInto_Failed_ServerError(t *testing.T) {
+	server := createConfiguredTestServer(testServerConfig{
+		status: http.StatusInternalServerError,
+		header: http.Header{"Content-Type": {ContentTypeJSON}},
+		body:   `{}`,
+	})
+	defer server.Close()
+
+	var bodies []*trackingBody
+	client := createTrackingTestClient(server.URL, &bodies)
+
+	_, err := client.PostInto(context.Background(), Parameter{Path: "/ping"}, nil)
+
+	var httpErr *HTTPError
+	require.True(t, errors.As(err, &httpErr))
+
+	require.Len(t, bodies, 1)
+	assert.True(t, bodies[0].closed)
+}
+
+func Test_DoInto_Failed_Decode(t *testing.T) {
+	server := createConfiguredTestServer(testServerConfig{
+		header: http.Header{"Content-Type": {ContentTypeJSON}},
+		body:   `{ "response": `,
+	})
+	defer server.Close()
+
+	var bodies []*trackingBody
+	client := createTrackingTestClient(server.URL, &bodies)
+
+	var out map[string]string
+	_, err := client.GetInto(context.Background(), Parameter{Path: "/ping"}, &out)
+
+	var decodeErr *DecodeError
+	require.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, http.StatusOK, decodeErr.StatusCode)
+	assert.Equal(t, ContentTypeJSON, decodeErr.ContentType)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	require.Len(t, bodies, 1)
+	assert.True(t, bodies[0].closed)
+}
+
+func Test_DoInto_Failed_UnknownContentType(t *testing.T) {
+	server := createConfiguredTestServer(testServerConfig{
+		header: http.Header{"Content-Type": {"text/some-unknown"}},
+		body:   `response`,
+	})
+	defer server.Close()
+
+	client := NewHttpClient(Config{Host: server.URL})
+
+	var out map[string]string
+	_, err := client.GetInto(context.Background(), Parameter{Path: "/ping"}, &out)
+
+	var decodeErr *DecodeError
+	require.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, "text/some-unknown", decodeErr.ContentType)
+}
@@ -0,0 +1,195 @@
+package httpclient
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/afex/hystrix-go/hystrix"
+)
+
+// sentinel errors, check them with errors.Is
+var (
+	// ErrBodyNotReplayable is returned when a failing request needs to be retried
+	// but its body has been consumed and the request has no GetBody to rewind it
+	ErrBodyNotReplayable = errors.New("httpclient: request body is not replayable, retry refused")
+
+	// ErrCircuitOpen is returned when the circuit is open and the attempt is short-circuited
+	ErrCircuitOpen = errors.New("httpclient: circuit open")
+
+	// ErrTimeout is returned when the attempt exceeds circuit breaker's timeout
+	ErrTimeout = errors.New("httpclient: circuit timeout")
+
+	// ErrMaxConcurrency is returned when the circuit has too many attempts running at the same time
+	ErrMaxConcurrency = errors.New("httpclient: circuit max concurrency reached")
+
+	// ErrRetriesExhausted is returned when every retry attempt has failed
+	ErrRetriesExhausted = errors.New("httpclient: retries exhausted")
+)
+
+// httpErrorBodySnippetSize is the maximum size of response body kept in HTTPError
+const httpErrorBodySnippetSize = 512
+
+// HTTPError is returned when a response is classified as failure by ResponseClassifier,
+// or on non-2xx response when decoding via DoInto.
+// it still carries the response, whose body is left unread for the caller
+type HTTPError struct {
+	Response *http.Response
+	Header   http.Header // response headers
+	Body     []byte      // beginning of the response body, at most 512 bytes
+}
+
+// newHTTPError initialises HTTPError, peeking the response body snippet
+// without consuming it for the caller
+func newHTTPError(res *http.Response) *HTTPError {
+	snippet, _ := ioutil.ReadAll(io.LimitReader(res.Body, httpErrorBodySnippetSize))
+	res.Body = &peekedBody{
+		Reader: io.MultiReader(bytes.NewReader(snippet), res.Body),
+		Closer: res.Body,
+	}
+
+	return &HTTPError{
+		Response: res,
+		Header:   res.Header,
+		Body:     snippet,
+	}
+}
+
+// peekedBody is a response body whose beginning has been read and put back
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// Error implements error
+func (e *HTTPError) Error() string {
+	// response built by middleware or fallback may carry no request
+	msg := fmt.Sprintf("httpclient: responded with status %d", e.Response.StatusCode)
+	if req := e.Response.Request; req != nil {
+		msg = fmt.Sprintf("httpclient: %s %s responded with status %d", req.Method, req.URL, e.Response.StatusCode)
+	}
+	if body := strings.TrimSpace(string(e.Body)); body != "" {
+		msg = fmt.Sprintf("%s: %s", msg, body)
+	}
+	return msg
+}
+
+// StatusCode returns the response's status code
+func (e *HTTPError) StatusCode() int {
+	return e.Response.StatusCode
+}
+
+// CircuitError is returned when circuit breaker rejects an attempt,
+// it matches both its sentinel (ErrCircuitOpen, ErrTimeout, ErrMaxConcurrency)
+// and the originating hystrix error with errors.Is
+type CircuitError struct {
+	CommandKey string
+	Err        error // one of ErrCircuitOpen, ErrTimeout, ErrMaxConcurrency
+	cause      error // originating hystrix error
+}
+
+// Error implements error
+func (e *CircuitError) Error() string {
+	return fmt.Sprintf("%v, command %q", e.Err, e.CommandKey)
+}
+
+// Unwrap returns the sentinel error
+func (e *CircuitError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches the originating hystrix error
+func (e *CircuitError) Is(target error) bool {
+	return target == e.cause
+}
+
+// wrapCircuitError is a helper to convert hystrix errors into CircuitError,
+// other errors are returned as is
+func wrapCircuitError(commandKey string, err error) error {
+	var sentinel error
+	switch err {
+	case hystrix.ErrCircuitOpen:
+		sentinel = ErrCircuitOpen
+	case hystrix.ErrTimeout:
+		sentinel = ErrTimeout
+	case hystrix.ErrMaxConcurrency:
+		sentinel = ErrMaxConcurrency
+	default:
+		return err
+	}
+	return &CircuitError{CommandKey: commandKey, Err: sentinel, cause: err}
+}
+
+// FallbackError is returned when circuit breaker's fallback fails with a different error than it was given
+type FallbackError struct {
+	Err   error // error returned by fallback
+	Cause error // error which triggered the fallback
+}
+
+// Error implements error
+func (e *FallbackError) Error() string {
+	return fmt.Sprintf("httpclient: fallback failed with %v, caused by %v", e.Err, e.Cause)
+}
+
+// Unwrap returns the fallback's error
+func (e *FallbackError) Unwrap() error {
+	return e.Err
+}
+
+// RetryError is returned when the request is still failing after being retried,
+// it matches ErrRetriesExhausted and unwraps to the last attempt's error
+type RetryError struct {
+	Attempts []error // error of every attempt, in order
+}
+
+// Error implements error
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%v after %d attempts: %v", ErrRetriesExhausted, len(e.Attempts), e.Last())
+}
+
+// Last returns the last attempt's error
+func (e *RetryError) Last() error {
+	return e.Attempts[len(e.Attempts)-1]
+}
+
+// Unwrap returns the last attempt's error
+func (e *RetryError) Unwrap() error {
+	return e.Last()
+}
+
+// Is matches ErrRetriesExhausted
+func (e *RetryError) Is(target error) bool {
+	return target == ErrRetriesExhausted
+}
+
+// ConfigError is returned by NewHttpClientE on invalid config
+type ConfigError struct {
+	Field  string
+	Reason string
+}
+
+// Error implements error
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("httpclient: invalid config %s: %s", e.Field, e.Reason)
+}
+
+// DecodeError is returned when a response body cannot be decoded into the given object
+type DecodeError struct {
+	StatusCode  int
+	ContentType string
+	Err         error
+}
+
+// Error implements error
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("httpclient: failed decoding %q response with status %d: %v", e.ContentType, e.StatusCode, e.Err)
+}
+
+// Unwrap returns the underlying decoding error
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,184 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WrapCircuitError(t *testing.T) {
+	testCases := map[error]error{
+		hystrix.ErrCircuitOpen:    ErrCircuitOpen,
+		hystrix.ErrTimeout:        ErrTimeout,
+		hystrix.ErrMaxConcurrency: ErrMaxConcurrency,
+	}
+
+	for hystrixErr, sentinel := range testCases {
+		err := wrapCircuitError("some-command", hystrixErr)
+
+		var circuitErr *CircuitError
+		require.True(t, errors.As(err, &circuitErr))
+		assert.Equal(t, "some-command", circuitErr.CommandKey)
+		assert.ErrorIs(t, err, sentinel)
+		assert.ErrorIs(t, err, hystrixErr)
+	}
+
+	someErr := errors.New("some-error")
+	assert.Equal(t, someErr, wrapCircuitError("some-command", someErr))
+}
+
+func Test_HTTPError_BodySnippet(t *testing.T) {
+	body := strings.Repeat("a", httpErrorBodySnippetSize) + "tail"
+	server := createConfiguredTestServer(testServerConfig{
+		status: http.StatusBadGateway,
+		header: http.Header{"Content-Type": {ContentTypeJSON}},
+		body:   body,
+	})
+	defer server.Close()
+
+	client := NewHttpClient(Config{Host: server.URL})
+
+	response, err := client.Get(Parameter{Path: "/ping"})
+
+	var httpErr *HTTPError
+	require.True(t, errors.As(err, &httpErr))
+	assert.Equal(t, http.StatusBadGateway, httpErr.StatusCode())
+	assert.Equal(t, ContentTypeJSON, httpErr.Header.Get("Content-Type"))
+	assert.Equal(t, strings.Repeat("a", httpErrorBodySnippetSize), string(httpErr.Body))
+
+	// body is still fully readable by the caller
+	b, err := ioutil.ReadAll(response.Body)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(b))
+	response.Body.Close()
+}
+
+func Test_HTTPError_WithoutRequest(t *testing.T) {
+	err := newHTTPError(&http.Response{
+		StatusCode: http.StatusBadGateway,
+		Body:       ioutil.NopCloser(strings.NewReader(`{ "error": "bad gateway" }`)),
+	})
+
+	assert.Equal(t, `httpclient: responded with status 502: { "error": "bad gateway" }`, err.Error())
+}
+
+func Test_RetryError(t *testing.T) {
+	server := createConfiguredTestServer(testServerConfig{
+		status: http.StatusServiceUnavailable,
+		header: http.Header{"Content-Type": {ContentTypeJSON}},
+		body:   `{}`,
+	})
+	defer server.Close()
+
+	client := NewHttpClient(Config{
+		Host:        server.URL,
+		RetryCount:  2,
+		RetryPolicy: ConstantRetryPolicy{Delay: time.Millisecond},
+	})
+
+	response, err := client.Get(Parameter{Path: "/ping"})
+	require.Error(t, err)
+	response.Body.Close()
+
+	var retryErr *RetryError
+	require.True(t, errors.As(err, &retryErr))
+	assert.ErrorIs(t, err, ErrRetriesExhausted)
+	require.Len(t, retryErr.Attempts, 3)
+	for _, attempt := range retryErr.Attempts {
+		var httpErr *HTTPError
+		assert.True(t, errors.As(attempt, &httpErr))
+	}
+
+	// last attempt's error is still reachable
+	var httpErr *HTTPError
+	assert.True(t, errors.As(err, &httpErr))
+}
+
+func Test_RetryError_NotOnSingleAttempt(t *testing.T) {
+	server := createConfiguredTestServer(testServerConfig{
+		status: http.StatusServiceUnavailable,
+		header: http.Header{"Content-Type": {ContentTypeJSON}},
+		body:   `{}`,
+	})
+	defer server.Close()
+
+	client := NewHttpClient(Config{Host: server.URL})
+
+	response, err := client.Get(Parameter{Path: "/ping"})
+	require.Error(t, err)
+	response.Body.Close()
+
+	assert.False(t, errors.Is(err, ErrRetriesExhausted))
+}
+
+func Test_CircuitError_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	commandName := createCommandName(t)
+	client := NewHttpClient(Config{
+		Host:                  server.URL,
+		IsUsingCircuitBreaker: true,
+		CommandNamer:          CommandNamerByName(commandName),
+		CbConfig: CircuitBreakerConfig{
+			Timeout: 50,
+		},
+	})
+
+	response, err := client.Get(Parameter{Path: "/ping"})
+	assert.Nil(t, response)
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.ErrorIs(t, err, hystrix.ErrTimeout)
+
+	var circuitErr *CircuitError
+	require.True(t, errors.As(err, &circuitErr))
+	assert.Equal(t, commandName, circuitErr.CommandKey)
+}
+
+func Test_FallbackError(t *testing.T) {
+	server := createConfiguredTestServer(testServerConfig{
+		status: http.StatusServiceUnavailable,
+		header: http.Header{"Content-Type": {ContentTypeJSON}},
+		body:   `{}`,
+	})
+	defer server.Close()
+
+	someErr := errors.New("some-fallback-error")
+	var fallbackCause error
+	client := NewHttpClient(Config{
+		Host:                  server.URL,
+		IsUsingCircuitBreaker: true,
+		CommandNamer:          CommandNamerByName(createCommandName(t)),
+		CbConfig: CircuitBreakerConfig{
+			Fallback: func(ctx context.Context, e error) error {
+				fallbackCause = e
+				return someErr
+			},
+		},
+	})
+
+	response, err := client.Get(Parameter{Path: "/ping"})
+	require.Error(t, err)
+	response.Body.Close()
+
+	var fallbackErr *FallbackError
+	require.True(t, errors.As(err, &fallbackErr))
+	assert.ErrorIs(t, err, someErr)
+
+	var httpErr *HTTPError
+	assert.True(t, errors.As(fallbackErr.Cause, &httpErr))
+	assert.Equal(t, fallbackCause, fallbackErr.Cause)
+}
@@ -0,0 +1,177 @@
+package httpclient
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix"
+)
+
+// EventType is the kind of an Event
+type EventType string
+
+const (
+	EventOpen     EventType = "open"      // circuit has opened
+	EventHalfOpen EventType = "half-open" // circuit's sleep window has elapsed, a test request is allowed
+	EventClose    EventType = "close"     // circuit has closed
+	EventFallback EventType = "fallback"  // fallback has replaced the outcome of a failing call
+	EventRetry    EventType = "retry"     // failing request is about to be retried
+	EventReject   EventType = "reject"    // attempt is rejected by an open circuit or max concurrency
+)
+
+// Event is an occurrence on a circuit, emitted to Config.EventListeners
+type Event struct {
+	Type       EventType
+	CommandKey string
+	Reason     string    // human readable reason of the event
+	Err        error     // error causing the event, nil on circuit transitions
+	Attempt    int       // retry number, only on EventRetry
+	Time       time.Time // when the event is detected
+}
+
+// EventListener receives circuit events.
+// hystrix doesn't offer callbacks, circuit transitions are detected by checking circuit state around each call,
+// or on CircuitInspector calls, so they are reported once observed.
+// listeners are called synchronously on the request's goroutine, they must not block
+type EventListener interface {
+	OnOpen(event Event)
+	OnHalfOpen(event Event)
+	OnClose(event Event)
+	OnFallback(event Event)
+	OnRetry(event Event)
+	OnReject(event Event)
+}
+
+// NopEventListener ignores every event,
+// embed it to implement only the needed methods of EventListener
+type NopEventListener struct{}
+
+func (NopEventListener) OnOpen(event Event)     {}
+func (NopEventListener) OnHalfOpen(event Event) {}
+func (NopEventListener) OnClose(event Event)    {}
+func (NopEventListener) OnFallback(event Event) {}
+func (NopEventListener) OnRetry(event Event)    {}
+func (NopEventListener) OnReject(event Event)   {}
+
+// EventChannel is an EventListener delivering every event into a buffered channel.
+// events are dropped instead of blocking the request when the buffer is full
+type EventChannel struct {
+	events  chan Event
+	dropped int64
+}
+
+// NewEventChannel initiates EventChannel with designated buffer size
+func NewEventChannel(size int) *EventChannel {
+	return &EventChannel{events: make(chan Event, size)}
+}
+
+// Events returns the channel to receive events from
+func (c *EventChannel) Events() <-chan Event {
+	return c.events
+}
+
+// Dropped returns number of events dropped due to full buffer
+func (c *EventChannel) Dropped() int64 {
+	return atomic.LoadInt64(&c.dropped)
+}
+
+// send is a helper to deliver event without blocking
+func (c *EventChannel) send(event Event) {
+	select {
+	case c.events <- event:
+	default:
+		atomic.AddInt64(&c.dropped, 1)
+	}
+}
+
+func (c *EventChannel) OnOpen(event Event)     { c.send(event) }
+func (c *EventChannel) OnHalfOpen(event Event) { c.send(event) }
+func (c *EventChannel) OnClose(event Event)    { c.send(event) }
+func (c *EventChannel) OnFallback(event Event) { c.send(event) }
+func (c *EventChannel) OnRetry(event Event)    { c.send(event) }
+func (c *EventChannel) OnReject(event Event)   { c.send(event) }
+
+// eventLogMessages are log messages of every event type
+var eventLogMessages = map[EventType]string{
+	EventOpen:     "circuit opened",
+	EventHalfOpen: "circuit half-opened",
+	EventClose:    "circuit closed",
+	EventFallback: "fallback served",
+	EventRetry:    "retrying request",
+	EventReject:   "attempt rejected",
+}
+
+// emit logs event and dispatches it to every registered listener
+func (hc *Client) emit(event Event) {
+	hc.logEvent(event)
+
+	if len(hc.config.EventListeners) == 0 {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	for _, listener := range hc.config.EventListeners {
+		switch event.Type {
+		case EventOpen:
+			listener.OnOpen(event)
+		case EventHalfOpen:
+			listener.OnHalfOpen(event)
+		case EventClose:
+			listener.OnClose(event)
+		case EventFallback:
+			listener.OnFallback(event)
+		case EventRetry:
+			listener.OnRetry(event)
+		case EventReject:
+			listener.OnReject(event)
+		}
+	}
+}
+
+// emitTransition emits event of a circuit moving from one state to another
+func (hc *Client) emitTransition(key string, from, to CircuitState) {
+	if from == to {
+		return
+	}
+
+	event := Event{CommandKey: key}
+	switch to {
+	case CircuitOpen:
+		event.Type = EventOpen
+		event.Reason = "error percentage threshold reached"
+		if settings, ok := hystrix.GetCircuitSettings()[key]; ok {
+			event.Reason = fmt.Sprintf("error percentage reached %d%%", settings.ErrorPercentThreshold)
+		}
+		if from == CircuitHalfOpen {
+			event.Reason = "test request failed"
+		}
+	case CircuitHalfOpen:
+		event.Type = EventHalfOpen
+		event.Reason = fmt.Sprintf("sleep window of %dms elapsed", hc.config.CbConfig.SleepWindow)
+	case CircuitClosed:
+		event.Type = EventClose
+		event.Reason = "test request succeeded"
+	}
+	hc.emit(event)
+}
+
+// logEvent is a helper to log event, circuit opening and degraded calls are warnings
+func (hc *Client) logEvent(event Event) {
+	keyvals := []interface{}{"client", hc.config.Name, "command", event.CommandKey, "reason", event.Reason}
+	if event.Type == EventRetry {
+		keyvals = append(keyvals, "attempt", event.Attempt)
+	}
+
+	msg := eventLogMessages[event.Type]
+	switch event.Type {
+	case EventOpen, EventFallback, EventRetry:
+		hc.config.Logger.Warn(msg, keyvals...)
+	case EventReject:
+		hc.config.Logger.Debug(msg, keyvals...)
+	default:
+		hc.config.Logger.Info(msg, keyvals...)
+	}
+}
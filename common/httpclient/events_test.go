@@ -0,0 +1,154 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// this is just a helper
+func drainEvents(events *EventChannel) []Event {
+	drained := []Event{}
+	for {
+		select {
+		case event := <-events.Events():
+			drained = append(drained, event)
+		default:
+			return drained
+		}
+	}
+}
+
+func Test_EventChannel_DropsWhenFull(t *testing.T) {
+	events := NewEventChannel(1)
+	events.OnOpen(Event{Type: EventOpen})
+	events.OnClose(Event{Type: EventClose})
+
+	drained := drainEvents(events)
+	require.Len(t, drained, 1)
+	assert.Equal(t, EventOpen, drained[0].Type)
+	assert.Equal(t, int64(1), events.Dropped())
+}
+
+func Test_Events_FullCircuitCycle(t *testing.T) {
+	status := int32(http.StatusInternalServerError)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+	}))
+	defer server.Close()
+
+	errDegraded := errors.New("degraded")
+	events := NewEventChannel(64)
+	commandName := createCommandName(t)
+	client := NewHttpClient(Config{
+		Host:                  server.URL,
+		IsUsingCircuitBreaker: true,
+		CommandNamer:          CommandNamerByName(commandName),
+		CbConfig: CircuitBreakerConfig{
+			SleepWindow:            100,
+			RequestVolumeThreshold: 3,
+			Fallback: func(ctx context.Context, err error) error {
+				return fmt.Errorf("%w: %v", errDegraded, err)
+			},
+		},
+		EventListeners: []EventListener{events},
+	})
+	inspector := client.(CircuitInspector)
+
+	// closed -> open
+	for i := 0; i < 3; i++ {
+		_, err := client.Get(Parameter{Path: "/ping"})
+		require.ErrorIs(t, err, errDegraded)
+	}
+	require.Eventually(t, func() bool {
+		return inspector.CircuitState()[commandName] == CircuitOpen
+	}, time.Second, 10*time.Millisecond)
+
+	// rejected while open
+	_, err := client.Get(Parameter{Path: "/ping"})
+	var fallbackErr *FallbackError
+	require.True(t, errors.As(err, &fallbackErr))
+	require.ErrorIs(t, fallbackErr.Cause, ErrCircuitOpen)
+
+	// open -> half-open
+	require.Eventually(t, func() bool {
+		return inspector.CircuitState()[commandName] == CircuitHalfOpen
+	}, time.Second, 10*time.Millisecond)
+
+	// half-open -> closed
+	atomic.StoreInt32(&status, http.StatusOK)
+	response, err := client.Get(Parameter{Path: "/ping"})
+	require.NoError(t, err)
+	response.Body.Close()
+	require.Eventually(t, func() bool {
+		return inspector.CircuitState()[commandName] == CircuitClosed
+	}, time.Second, 10*time.Millisecond)
+
+	transitions := []EventType{}
+	fallbacks := 0
+	for _, event := range drainEvents(events) {
+		assert.Equal(t, commandName, event.CommandKey)
+		assert.NotEmpty(t, event.Reason)
+		assert.False(t, event.Time.IsZero())
+
+		if event.Type == EventFallback {
+			fallbacks++
+			continue
+		}
+		transitions = append(transitions, event.Type)
+
+		if event.Type == EventReject {
+			assert.ErrorIs(t, event.Err, ErrCircuitOpen)
+		}
+	}
+	assert.Equal(t, []EventType{EventOpen, EventReject, EventHalfOpen, EventClose}, transitions)
+	assert.Equal(t, 4, fallbacks)
+	assert.Equal(t, int64(0), events.Dropped())
+}
+
+func Test_Events_Retry(t *testing.T) {
+	var hits int32
+	server := createConfiguredTestServer(testServerConfig{status: http.StatusServiceUnavailable, hits: &hits})
+	defer server.Close()
+
+	events := NewEventChannel(8)
+	client := NewHttpClient(Config{
+		Host:           server.URL,
+		RetryCount:     2,
+		RetryPolicy:    ConstantRetryPolicy{Delay: time.Millisecond},
+		EventListeners: []EventListener{events},
+	})
+
+	response, err := client.Get(Parameter{Path: "/ping"})
+	require.Error(t, err)
+	response.Body.Close()
+
+	drained := drainEvents(events)
+	require.Len(t, drained, 2)
+	for i, event := range drained {
+		assert.Equal(t, EventRetry, event.Type)
+		assert.Equal(t, server.URL, event.CommandKey)
+		assert.Equal(t, i+1, event.Attempt)
+
+		var httpErr *HTTPError
+		assert.True(t, errors.As(event.Err, &httpErr))
+	}
+}
+
+func Test_Events_NopEventListener(t *testing.T) {
+	var listener EventListener = struct{ NopEventListener }{}
+
+	assert.NotPanics(t, func() {
+		listener.OnOpen(Event{})
+		listener.OnReject(Event{})
+	})
+}
@@ -0,0 +1,218 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// HeaderFallback marks a response served by ResponseFallback instead of the dependency
+const HeaderFallback = "X-Httpclient-Fallback"
+
+// ResponseFallback serves a degraded response when a call still fails after its retries, including rejections by circuit breaker,
+// e.g. a cached value, a static default or an answer from an alternate host.
+// err is the call's failure, returning an error means no response can be served.
+// it requires Config.IsUsingCircuitBreaker
+type ResponseFallback func(ctx context.Context, req *http.Request, err error) (*http.Response, error)
+
+// IsFallbackResponse checks whether a response is served by ResponseFallback
+func IsFallbackResponse(res *http.Response) bool {
+	return res != nil && res.Header.Get(HeaderFallback) != ""
+}
+
+// markFallbackResponse is a helper to flag a fallback response for the caller
+func markFallbackResponse(req *http.Request, res *http.Response) {
+	if res.Header == nil {
+		res.Header = http.Header{}
+	}
+	res.Header.Set(HeaderFallback, "true")
+	if res.Request == nil {
+		res.Request = req
+	}
+}
+
+// newResponse is a helper to build a response with in-memory body
+func newResponse(req *http.Request, status int, header http.Header, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// StaticFallback serves the same response on every failure
+func StaticFallback(status int, header http.Header, body []byte) ResponseFallback {
+	return func(ctx context.Context, req *http.Request, err error) (*http.Response, error) {
+		return newResponse(req, status, header, body), nil
+	}
+}
+
+// responseClassifierKey is the context key to carry the client's ResponseClassifier to ResponseFallback
+type responseClassifierKey struct{}
+
+// withResponseClassifier is a helper to attach response classifier into context
+func withResponseClassifier(ctx context.Context, classifier ResponseClassifier) context.Context {
+	return context.WithValue(ctx, responseClassifierKey{}, classifier)
+}
+
+// responseClassifier is a helper to get response classifier of the calling client,
+// default DefaultResponseClassifier
+func responseClassifier(ctx context.Context) ResponseClassifier {
+	classifier, ok := ctx.Value(responseClassifierKey{}).(ResponseClassifier)
+	if !ok {
+		return DefaultResponseClassifier
+	}
+	return classifier
+}
+
+// AlternateHostFallback resends the failing request to another host, e.g. "http://zulu-backup:3002".
+// doer executes the request, default http client with the same default timeout as HttpClient.
+// response flagged as failure by the client's ResponseClassifier fails the fallback as *HTTPError.
+// the request body must be replayable, see ErrBodyNotReplayable
+func AlternateHostFallback(host string, doer Doer) ResponseFallback {
+	if doer == nil {
+		doer = &http.Client{Timeout: defautTimeout}
+	}
+
+	return func(ctx context.Context, req *http.Request, err error) (*http.Response, error) {
+		alternate, errParse := url.Parse(host)
+		if errParse != nil {
+			return nil, errParse
+		}
+
+		altReq, errRewind := rewindRequest(req)
+		if errRewind != nil {
+			return nil, errRewind
+		}
+		altReq = altReq.WithContext(ctx)
+		altReq.URL.Scheme = alternate.Scheme
+		altReq.URL.Host = alternate.Host
+		altReq.Host = ""
+
+		res, errDo := doer.Do(altReq)
+		if errDo != nil {
+			return nil, errDo
+		}
+
+		// failing alternate host is no better than the primary one
+		if responseClassifier(ctx)(res) {
+			errHTTP := newHTTPError(res)
+			res.Body.Close()
+			return nil, errHTTP
+		}
+
+		return res, nil
+	}
+}
+
+// ChainFallbacks tries every fallback in order until one of them serves a response,
+// the last failure is returned when none does
+func ChainFallbacks(fallbacks ...ResponseFallback) ResponseFallback {
+	return func(ctx context.Context, req *http.Request, err error) (*http.Response, error) {
+		errLast := err
+		for _, fallback := range fallbacks {
+			res, errFallback := fallback(ctx, req, err)
+			if errFallback == nil && res != nil {
+				return res, nil
+			}
+			if errFallback != nil {
+				errLast = errFallback
+			}
+		}
+		return nil, errLast
+	}
+}
+
+// LastKnownGoodCache keeps the last successful GET response per url,
+// serving it back as fallback when the dependency fails.
+// the cache needs both of its parts registered:
+//
+//	cache := httpclient.NewLastKnownGoodCache(time.Hour)
+//	httpclient.Config{
+//		AttemptMiddlewares: []httpclient.Middleware{cache.Middleware()},
+//		CbConfig: httpclient.CircuitBreakerConfig{ResponseFallback: cache.Fallback()},
+//	}
+type LastKnownGoodCache struct {
+	maxAge  time.Duration
+	mutex   *sync.RWMutex
+	entries map[string]cachedResponse
+}
+
+// cachedResponse is a successful response kept by LastKnownGoodCache
+type cachedResponse struct {
+	status   int
+	header   http.Header
+	body     []byte
+	storedAt time.Time
+}
+
+// NewLastKnownGoodCache initialises LastKnownGoodCache,
+// entries older than maxAge are not served, zero maxAge means no expiry
+func NewLastKnownGoodCache(maxAge time.Duration) *LastKnownGoodCache {
+	return &LastKnownGoodCache{
+		maxAge:  maxAge,
+		mutex:   &sync.RWMutex{},
+		entries: map[string]cachedResponse{},
+	}
+}
+
+// cacheKey is a helper to resolve cache key of a request
+func cacheKey(req *http.Request) string {
+	return req.Method + " " + req.URL.String()
+}
+
+// Middleware records every successful GET response
+func (c *LastKnownGoodCache) Middleware() Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			res, err := next.Do(req)
+			if err != nil || req.Method != http.MethodGet || res.StatusCode < 200 || res.StatusCode > 299 {
+				return res, err
+			}
+
+			body, errRead := ioutil.ReadAll(res.Body)
+			res.Body.Close()
+			if errRead != nil {
+				return nil, errRead
+			}
+			res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+			c.mutex.Lock()
+			c.entries[cacheKey(req)] = cachedResponse{
+				status:   res.StatusCode,
+				header:   res.Header.Clone(),
+				body:     body,
+				storedAt: time.Now(),
+			}
+			c.mutex.Unlock()
+
+			return res, nil
+		})
+	}
+}
+
+// Fallback serves the last successful response of the same url
+func (c *LastKnownGoodCache) Fallback() ResponseFallback {
+	return func(ctx context.Context, req *http.Request, err error) (*http.Response, error) {
+		c.mutex.RLock()
+		entry, ok := c.entries[cacheKey(req)]
+		c.mutex.RUnlock()
+
+		if !ok || (c.maxAge > 0 && time.Since(entry.storedAt) > c.maxAge) {
+			return nil, err
+		}
+
+		return newResponse(req, entry.status, entry.header, entry.body), nil
+	}
+}
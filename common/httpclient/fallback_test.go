@@ -0,0 +1,265 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// this is just a helper
+func createFallbackTestClient(t *testing.T, host string, fallback ResponseFallback, middlewares ...Middleware) HttpClient {
+	return NewHttpClient(Config{
+		Host:                  host,
+		IsUsingCircuitBreaker: true,
+		CommandNamer:          CommandNamerByName(createCommandName(t)),
+		AttemptMiddlewares:    middlewares,
+		CbConfig: CircuitBreakerConfig{
+			ResponseFallback: fallback,
+		},
+	})
+}
+
+// this is just a helper
+func readBody(t *testing.T, res *http.Response) string {
+	defer res.Body.Close()
+	b, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func Test_StaticFallback(t *testing.T) {
+	server := createConfiguredTestServer(testServerConfig{
+		status: http.StatusServiceUnavailable,
+		header: http.Header{"Content-Type": {ContentTypeJSON}},
+		body:   `{ "response": "not ok" }`,
+	})
+	defer server.Close()
+
+	client := createFallbackTestClient(t, server.URL, StaticFallback(
+		http.StatusOK,
+		http.Header{"Content-Type": {ContentTypeJSON}},
+		[]byte(`{ "response": "default" }`),
+	))
+
+	response, err := client.Get(Parameter{Path: "/ping"})
+	require.NoError(t, err)
+	assert.True(t, IsFallbackResponse(response))
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, ContentTypeJSON, response.Header.Get("Content-Type"))
+	assert.Equal(t, `{ "response": "default" }`, readBody(t, response))
+}
+
+func Test_StaticFallback_DoInto(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	client := createFallbackTestClient(t, server.URL, StaticFallback(
+		http.StatusOK,
+		http.Header{"Content-Type": {ContentTypeJSON}},
+		[]byte(`{ "response": "default" }`),
+	))
+
+	var out map[string]string
+	response, err := client.GetInto(context.Background(), Parameter{Path: "/ping"}, &out)
+	require.NoError(t, err)
+	assert.True(t, IsFallbackResponse(response))
+	assert.Equal(t, map[string]string{"response": "default"}, out)
+}
+
+func Test_ResponseFallback_AfterRetries(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// only the second hit, i.e. the first call's retry, succeeds
+		if atomic.AddInt32(&hits, 1) != 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("retried"))
+	}))
+	defer server.Close()
+
+	var fallbackErrs []error
+	client := NewHttpClient(Config{
+		Host:                  server.URL,
+		RetryCount:            1,
+		RetryPolicy:           ConstantRetryPolicy{Delay: time.Millisecond},
+		IsUsingCircuitBreaker: true,
+		CommandNamer:          CommandNamerByName(createCommandName(t)),
+		CbConfig: CircuitBreakerConfig{
+			ResponseFallback: func(ctx context.Context, req *http.Request, err error) (*http.Response, error) {
+				fallbackErrs = append(fallbackErrs, err)
+				return StaticFallback(http.StatusOK, nil, []byte("static"))(ctx, req, err)
+			},
+		},
+	})
+
+	// failing attempt is retried instead of served by fallback
+	response, err := client.Get(Parameter{Path: "/ping"})
+	require.NoError(t, err)
+	assert.False(t, IsFallbackResponse(response))
+	assert.Equal(t, "retried", readBody(t, response))
+	assert.Empty(t, fallbackErrs)
+
+	// fallback is served once retries are exhausted
+	response, err = client.Get(Parameter{Path: "/ping"})
+	require.NoError(t, err)
+	assert.True(t, IsFallbackResponse(response))
+	assert.Equal(t, "static", readBody(t, response))
+	assert.Equal(t, int32(4), atomic.LoadInt32(&hits))
+	require.Len(t, fallbackErrs, 1)
+
+	var retryErr *RetryError
+	assert.True(t, errors.As(fallbackErrs[0], &retryErr))
+}
+
+func Test_LastKnownGoodCache(t *testing.T) {
+	var isFailing int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&isFailing) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{ "response": "ok" }`))
+	}))
+	defer server.Close()
+
+	cache := NewLastKnownGoodCache(0)
+	client := createFallbackTestClient(t, server.URL, cache.Fallback(), cache.Middleware())
+
+	// no cached response yet
+	atomic.StoreInt32(&isFailing, 1)
+	response, err := client.Get(Parameter{Path: "/ping"})
+	var httpErr *HTTPError
+	require.True(t, errors.As(err, &httpErr))
+	response.Body.Close()
+
+	atomic.StoreInt32(&isFailing, 0)
+	response, err = client.Get(Parameter{Path: "/ping"})
+	require.NoError(t, err)
+	assert.False(t, IsFallbackResponse(response))
+	assert.Equal(t, `{ "response": "ok" }`, readBody(t, response))
+
+	atomic.StoreInt32(&isFailing, 1)
+	response, err = client.Get(Parameter{Path: "/ping"})
+	require.NoError(t, err)
+	assert.True(t, IsFallbackResponse(response))
+	assert.Equal(t, `{ "response": "ok" }`, readBody(t, response))
+
+	// different url is not served
+	response, err = client.Get(Parameter{Path: "/another"})
+	require.Error(t, err)
+	response.Body.Close()
+}
+
+func Test_AlternateHostFallback(t *testing.T) {
+	primary := createConfiguredTestServer(testServerConfig{
+		status: http.StatusServiceUnavailable,
+		header: http.Header{"Content-Type": {ContentTypeJSON}},
+		body:   `{}`,
+	})
+	defer primary.Close()
+
+	var receivedPath, receivedBody string
+	alternate := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		receivedPath, receivedBody = r.URL.RequestURI(), string(b)
+		w.Write([]byte(`{ "response": "alternate" }`))
+	}))
+	defer alternate.Close()
+
+	client := createFallbackTestClient(t, primary.URL, AlternateHostFallback(alternate.URL, nil))
+
+	response, err := client.Post(Parameter{
+		Path:        "/ping",
+		QueryParams: map[string]string{"a": "b"},
+		Body:        map[string]string{"someKey": "someValue"},
+	})
+	require.NoError(t, err)
+	assert.True(t, IsFallbackResponse(response))
+	assert.Equal(t, `{ "response": "alternate" }`, readBody(t, response))
+	assert.Equal(t, "/ping?a=b", receivedPath)
+	assert.Equal(t, `{"someKey":"someValue"}`, receivedBody)
+}
+
+func Test_AlternateHostFallback_Failing(t *testing.T) {
+	primary := createConfiguredTestServer(testServerConfig{
+		status: http.StatusServiceUnavailable,
+		header: http.Header{"Content-Type": {ContentTypeJSON}},
+		body:   `{}`,
+	})
+	defer primary.Close()
+
+	alternate := createConfiguredTestServer(testServerConfig{
+		status: http.StatusBadGateway,
+		header: http.Header{"Content-Type": {ContentTypeJSON}},
+		body:   `{ "response": "alternate down" }`,
+	})
+	defer alternate.Close()
+
+	client := createFallbackTestClient(t, primary.URL, AlternateHostFallback(alternate.URL, nil))
+
+	response, err := client.Get(Parameter{Path: "/ping"})
+	require.Error(t, err)
+	response.Body.Close()
+
+	var fallbackErr *FallbackError
+	require.True(t, errors.As(err, &fallbackErr))
+	var httpErr *HTTPError
+	require.True(t, errors.As(fallbackErr.Err, &httpErr))
+	assert.Equal(t, http.StatusBadGateway, httpErr.Response.StatusCode)
+	assert.False(t, IsFallbackResponse(response))
+}
+
+func Test_ChainFallbacks(t *testing.T) {
+	server := createConfiguredTestServer(testServerConfig{
+		status: http.StatusServiceUnavailable,
+		header: http.Header{"Content-Type": {ContentTypeJSON}},
+		body:   `{}`,
+	})
+	defer server.Close()
+
+	var called []string
+	failing := func(ctx context.Context, req *http.Request, err error) (*http.Response, error) {
+		called = append(called, "failing")
+		return nil, errors.New("some-fallback-error")
+	}
+
+	client := createFallbackTestClient(t, server.URL, ChainFallbacks(
+		failing,
+		NewLastKnownGoodCache(0).Fallback(),
+		StaticFallback(http.StatusOK, nil, []byte("static")),
+	))
+
+	response, err := client.Get(Parameter{Path: "/ping"})
+	require.NoError(t, err)
+	assert.Equal(t, "static", readBody(t, response))
+	assert.Equal(t, []string{"failing"}, called)
+}
+
+func Test_ChainFallbacks_NoneServed(t *testing.T) {
+	server := createConfiguredTestServer(testServerConfig{
+		status: http.StatusServiceUnavailable,
+		header: http.Header{"Content-Type": {ContentTypeJSON}},
+		body:   `{}`,
+	})
+	defer server.Close()
+
+	client := createFallbackTestClient(t, server.URL, ChainFallbacks(
+		NewLastKnownGoodCache(0).Fallback(),
+	))
+
+	response, err := client.Get(Parameter{Path: "/ping"})
+	require.Error(t, err)
+	response.Body.Close()
+
+	var httpErr *HTTPError
+	assert.True(t, errors.As(err, &httpErr))
+}
@@ -0,0 +1,299 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HedgingConfig enables hedged GET and HEAD requests: when an attempt hasn't answered within a delay,
+// a second one is fired, whichever succeeds first is taken and the other is cancelled.
+// every hedge is an attempt of its own, going through circuit breaker and counting against its MaxConcurrentRequests.
+// hedges are not fired while the circuit is not closed
+type HedgingConfig struct {
+	Delay      time.Duration // wait before hedging, default 100ms. with Percentile, it is used until enough latency is sampled
+	Percentile float64       // 0-1, e.g. 0.95, hedging after that percentile of recent successful calls' latency, default off
+	MaxRatio   float64       // 0-1, hedges allowed per request, e.g. 0.1 caps hedging at 10% extra load, default 0.1
+}
+
+// HedgeObserver is optionally implemented by MetricsCollector to count fired hedges
+type HedgeObserver interface {
+	IncHedge(labels MetricLabels)
+}
+
+// default values for HedgingConfig
+const (
+	defaultHedgingDelay    = 100 * time.Millisecond
+	defaultHedgingMaxRatio = 0.1
+)
+
+const (
+	hedgingLatencyWindow = 100 // recent latencies kept for Percentile
+	hedgingMinSamples    = 20  // latencies needed before Percentile is used
+	hedgingBudgetBurst   = 10  // hedges which can be saved up while dependency is healthy
+)
+
+// hedgeableMethods are methods hedged, i.e. idempotent reads
+var hedgeableMethods = map[string]bool{
+	http.MethodGet:  true,
+	http.MethodHead: true,
+}
+
+// hedger keeps the state shared by every hedged call of a client,
+// i.e. recent latencies and the hedging budget
+type hedger struct {
+	config    HedgingConfig
+	mutex     *sync.Mutex
+	tokens    float64         // budget, every request deposits MaxRatio, every hedge withdraws 1
+	latencies []time.Duration // ring of recent successful calls' latency
+	next      int             // next ring position to overwrite
+}
+
+// newHedger initiates hedger, applying HedgingConfig defaults
+func newHedger(config HedgingConfig) *hedger {
+	// set default value if not defined
+	if config.Delay == 0 {
+		config.Delay = defaultHedgingDelay
+	}
+
+	// set default value if not defined
+	if config.MaxRatio == 0 {
+		config.MaxRatio = defaultHedgingMaxRatio
+	}
+
+	return &hedger{
+		config: config,
+		mutex:  &sync.Mutex{},
+	}
+}
+
+// delay resolves how long to wait before hedging
+func (h *hedger) delay() time.Duration {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.config.Percentile == 0 || len(h.latencies) < hedgingMinSamples {
+		return h.config.Delay
+	}
+
+	sorted := make([]time.Duration, len(h.latencies))
+	copy(sorted, h.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	i := int(math.Ceil(h.config.Percentile*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+// observe records latency of a successful call
+func (h *hedger) observe(latency time.Duration) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if len(h.latencies) < hedgingLatencyWindow {
+		h.latencies = append(h.latencies, latency)
+		return
+	}
+	h.latencies[h.next] = latency
+	h.next = (h.next + 1) % hedgingLatencyWindow
+}
+
+// deposit adds a request's share to the budget
+func (h *hedger) deposit() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.tokens = math.Min(h.tokens+h.config.MaxRatio, hedgingBudgetBurst)
+}
+
+// withdraw takes a hedge out of the budget, false when it is exhausted
+func (h *hedger) withdraw() bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.tokens < 1 {
+		return false
+	}
+	h.tokens--
+	return true
+}
+
+// hedgeAttemptKey is the context key of hedgeAttempt
+type hedgeAttemptKey struct{}
+
+// hedgeAttempt is one of the racing attempts of a hedged call
+type hedgeAttempt struct {
+	cancel     context.CancelFunc
+	isLost     int32        // set once another attempt has won, before cancelling this one
+	commandKey atomic.Value // command key resolved by circuit breaker for the attempt
+}
+
+// resolveHedgeCommandKey records command key resolved for an attempt, letting hedging check its circuit
+func resolveHedgeCommandKey(ctx context.Context, key string) {
+	if attempt, ok := ctx.Value(hedgeAttemptKey{}).(*hedgeAttempt); ok {
+		attempt.commandKey.Store(key)
+	}
+}
+
+// hedgeOutcome is the result of a hedgeAttempt
+type hedgeOutcome struct {
+	attempt *hedgeAttempt
+	res     *http.Response
+	err     error
+}
+
+// isLostHedge checks whether ctx belongs to an attempt cancelled because another one has won,
+// such attempt is neither a failure of the dependency nor worth a fallback
+func isLostHedge(ctx context.Context) bool {
+	attempt, ok := ctx.Value(hedgeAttemptKey{}).(*hedgeAttempt)
+	return ok && atomic.LoadInt32(&attempt.isLost) == 1
+}
+
+// cancelOnClose is a response body releasing its attempt's context once closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close implements io.Closer
+func (b *cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// hedgingMiddleware races a second attempt against a slow one
+func (hc *Client) hedgingMiddleware(next Doer) Doer {
+	h := newHedger(*hc.config.Hedging)
+
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		if !hedgeableMethods[req.Method] {
+			return next.Do(req)
+		}
+
+		h.deposit()
+		start := time.Now()
+		res, err := hc.doHedged(h, next, req)
+		if err == nil && !IsFallbackResponse(res) {
+			h.observe(time.Since(start))
+		}
+		return res, err
+	})
+}
+
+// doHedged is a helper to run the primary attempt and, once it's late, the hedge.
+// the first genuine success wins, fallback responses and responses classified as failure don't,
+// otherwise the primary's outcome is returned
+func (hc *Client) doHedged(h *hedger, next Doer, req *http.Request) (*http.Response, error) {
+	outcomes := make(chan hedgeOutcome, 2)
+	attempts := []*hedgeAttempt{}
+	launch := func(req *http.Request) {
+		ctx, cancel := context.WithCancel(req.Context())
+		attempt := &hedgeAttempt{cancel: cancel}
+		attempts = append(attempts, attempt)
+		req = req.WithContext(context.WithValue(ctx, hedgeAttemptKey{}, attempt))
+
+		go func() {
+			res, err := next.Do(req)
+			outcomes <- hedgeOutcome{attempt: attempt, res: res, err: err}
+		}()
+	}
+
+	launch(req)
+	pending := 1
+	timer := time.NewTimer(h.delay())
+	defer timer.Stop()
+
+	var primary hedgeOutcome
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			if !hc.canHedge(attempts[0]) || !h.withdraw() {
+				continue
+			}
+			hedgeReq, err := rewindRequest(req)
+			if err != nil {
+				continue
+			}
+
+			labels := hc.metricLabels(req)
+			if observer, ok := hc.config.MetricsCollector.(HedgeObserver); ok {
+				observer.IncHedge(labels)
+			}
+			hc.config.Logger.Debug("hedging request", "client", labels.Client, "method", req.Method, "url", req.URL.String())
+
+			launch(hedgeReq)
+			pending++
+
+		case outcome := <-outcomes:
+			pending--
+
+			if outcome.err == nil && !IsFallbackResponse(outcome.res) && !hc.config.ResponseClassifier(outcome.res) {
+				for _, attempt := range attempts {
+					if attempt != outcome.attempt {
+						atomic.StoreInt32(&attempt.isLost, 1)
+						attempt.cancel()
+					}
+				}
+				if primary.attempt != nil {
+					discardOutcome(primary)
+				}
+				go discardOutcomes(outcomes, pending)
+				return releaseOnClose(outcome), nil
+			}
+
+			if outcome.attempt == attempts[0] {
+				primary = outcome
+				continue
+			}
+			discardOutcome(outcome)
+		}
+	}
+
+	return releaseOnClose(primary), primary.err
+}
+
+// canHedge is a helper to check whether circuit of the primary attempt welcomes a hedge,
+// a circuit which is not closed marks an unhealthy dependency.
+// primary attempt not having reached circuit breaker yet is given the benefit of the doubt,
+// the hedge still goes through circuit breaker on its own
+func (hc *Client) canHedge(primary *hedgeAttempt) bool {
+	key, ok := primary.commandKey.Load().(string)
+	if !ok {
+		return true
+	}
+
+	tracker, ok := hc.commands.Load(key)
+	return !ok || tracker.(*circuitTracker).current() == CircuitClosed
+}
+
+// releaseOnClose is a helper to tie attempt's context to its response body,
+// cancelling the context right away when there is no body
+func releaseOnClose(outcome hedgeOutcome) *http.Response {
+	if outcome.res == nil || outcome.res.Body == nil {
+		outcome.attempt.cancel()
+		return outcome.res
+	}
+	outcome.res.Body = &cancelOnClose{ReadCloser: outcome.res.Body, cancel: outcome.attempt.cancel}
+	return outcome.res
+}
+
+// discardOutcome is a helper to close response of a losing attempt and release its context
+func discardOutcome(outcome hedgeOutcome) {
+	if outcome.res != nil {
+		outcome.res.Body.Close()
+	}
+	outcome.attempt.cancel()
+}
+
+// discardOutcomes is a helper to discard losing attempts still in flight
+func discardOutcomes(outcomes chan hedgeOutcome, pending int) {
+	for i := 0; i < pending; i++ {
+		discardOutcome(<-outcomes)
+	}
+}
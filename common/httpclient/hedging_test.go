@@ -0,0 +1,175 @@
+package httpclient
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Hedging_SlowAttempt(t *testing.T) {
+	var hits int32
+	cancelled := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+				cancelled <- struct{}{}
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		w.Write([]byte("hedge"))
+	}))
+	defer server.Close()
+
+	events := NewEventChannel(8)
+	commandName := createCommandName(t)
+	var namerCalls int32
+	client := NewHttpClient(Config{
+		Host:                  server.URL,
+		IsUsingCircuitBreaker: true,
+		CommandNamer: func(req *http.Request) string {
+			atomic.AddInt32(&namerCalls, 1)
+			return commandName
+		},
+		Hedging:        &HedgingConfig{Delay: 20 * time.Millisecond, MaxRatio: 1},
+		EventListeners: []EventListener{events},
+	})
+
+	start := time.Now()
+	response, err := client.Get(Parameter{Path: "/ping"})
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(response.Body)
+	require.NoError(t, err)
+	response.Body.Close()
+
+	assert.Equal(t, "hedge", string(body))
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+
+	// command key is resolved once per attempt, hedging reuses the primary's
+	assert.Equal(t, int32(2), atomic.LoadInt32(&namerCalls))
+
+	// loser is cancelled, without counting as circuit's failure nor being served a fallback
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("losing attempt is not cancelled")
+	}
+	assert.Empty(t, drainEvents(events))
+	require.Eventually(t, func() bool {
+		return client.(CircuitInspector).Metrics()[commandName].Requests == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(0), client.(CircuitInspector).Metrics()[commandName].Errors)
+}
+
+func Test_Hedging_OnlyIdempotentReads(t *testing.T) {
+	var hits int32
+	server := createConfiguredTestServer(testServerConfig{status: http.StatusNoContent, delay: 50 * time.Millisecond})
+	defer server.Close()
+
+	client := NewHttpClient(Config{
+		Host:    server.URL,
+		Hedging: &HedgingConfig{Delay: time.Millisecond, MaxRatio: 1},
+		AttemptMiddlewares: []Middleware{func(next Doer) Doer {
+			return DoerFunc(func(req *http.Request) (*http.Response, error) {
+				atomic.AddInt32(&hits, 1)
+				return next.Do(req)
+			})
+		}},
+	})
+
+	response, err := client.Post(Parameter{Path: "/ping", Body: map[string]string{"key": "value"}})
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
+
+func Test_Hedging_ClassifiedResponseDoesNotWin(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			time.Sleep(50 * time.Millisecond)
+			w.Write([]byte("primary"))
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewHttpClient(Config{
+		Host:    server.URL,
+		Hedging: &HedgingConfig{Delay: 10 * time.Millisecond, MaxRatio: 1},
+		AttemptMiddlewares: []Middleware{func(next Doer) Doer {
+			// tolerating failures, leaving them to ResponseClassifier
+			return DoerFunc(func(req *http.Request) (*http.Response, error) {
+				res, err := next.Do(req)
+				if res != nil {
+					return res, nil
+				}
+				return res, err
+			})
+		}},
+	})
+
+	response, err := client.Get(Parameter{Path: "/ping"})
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(response.Body)
+	require.NoError(t, err)
+	response.Body.Close()
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "primary", string(body))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+}
+
+func Test_Hedging_Budget(t *testing.T) {
+	var hits int32
+	server := createConfiguredTestServer(testServerConfig{status: http.StatusNoContent, delay: 20 * time.Millisecond})
+	defer server.Close()
+
+	client := NewHttpClient(Config{
+		Host:    server.URL,
+		Hedging: &HedgingConfig{Delay: time.Millisecond, MaxRatio: 0.25},
+		AttemptMiddlewares: []Middleware{func(next Doer) Doer {
+			return DoerFunc(func(req *http.Request) (*http.Response, error) {
+				atomic.AddInt32(&hits, 1)
+				return next.Do(req)
+			})
+		}},
+	})
+
+	for i := 0; i < 8; i++ {
+		response, err := client.Get(Parameter{Path: "/ping"})
+		require.NoError(t, err)
+		response.Body.Close()
+	}
+
+	// every request is late, yet only a quarter of them is hedged
+	assert.Equal(t, int32(8+2), atomic.LoadInt32(&hits))
+}
+
+func Test_Hedger_Delay(t *testing.T) {
+	h := newHedger(HedgingConfig{Delay: 50 * time.Millisecond, Percentile: 0.9})
+
+	// fixed delay until enough latency is sampled
+	for i := 1; i < hedgingMinSamples; i++ {
+		h.observe(time.Duration(i) * time.Millisecond)
+	}
+	assert.Equal(t, 50*time.Millisecond, h.delay())
+
+	h.observe(hedgingMinSamples * time.Millisecond)
+	assert.Equal(t, 18*time.Millisecond, h.delay())
+
+	// only recent latency counts
+	for i := 0; i < hedgingLatencyWindow; i++ {
+		h.observe(time.Second)
+	}
+	assert.Equal(t, time.Second, h.delay())
+}
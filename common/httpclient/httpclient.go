@@ -36,6 +36,22 @@ type HttpClient interface {
 	// with context in args
 	DeleteWithContext(ctx context.Context, param Parameter) (*http.Response, error)
 
+	// GetInto executes an http request with method GET
+	// and decodes the response body into out
+	GetInto(ctx context.Context, param Parameter, out interface{}) (*http.Response, error)
+
+	// PostInto executes an http request with method POST
+	// and decodes the response body into out
+	PostInto(ctx context.Context, param Parameter, out interface{}) (*http.Response, error)
+
+	// PutInto executes an http request with method PUT
+	// and decodes the response body into out
+	PutInto(ctx context.Context, param Parameter, out interface{}) (*http.Response, error)
+
+	// DeleteInto executes an http request with method DELETE
+	// and decodes the response body into out
+	DeleteInto(ctx context.Context, param Parameter, out interface{}) (*http.Response, error)
+
 	// Do is an in-house "native" httpclient which executes an http request to designated url/host,
 	// wrapped with circuit breaker functionality and retry mechanism
 	Do(httpMethod string, param Parameter) (*http.Response, error)
@@ -45,6 +61,10 @@ type HttpClient interface {
 	// with context in args
 	DoContext(ctx context.Context, httpMethod string, param Parameter) (*http.Response, error)
 
+	// DoInto executes an http request like DoContext and decodes the response body into out,
+	// the response body is always closed
+	DoInto(ctx context.Context, httpMethod string, param Parameter, out interface{}) (*http.Response, error)
+
 	// CAUTION: USE THIS AT YOUR OWN RISK
 	// DoVanilla is a vanilla version of in-house "native" httpclient which executes an http request to designated url/host
 	// wrapped with circuit breaker functionality and retry mechanism
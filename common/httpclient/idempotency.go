@@ -0,0 +1,54 @@
+package httpclient
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// HeaderIdempotencyKey is the header carrying idempotency key of a logical call,
+// letting downstream deduplicate retried non-idempotent requests
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// idempotentMethods are the http methods which are safe to be retried blindly
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+// isRetryable is a helper to check whether a request is safe to be retried,
+// non-idempotent methods, e.g. POST, need to opt in with idempotency key
+func isRetryable(req *http.Request) bool {
+	return idempotentMethods[req.Method] || req.Header.Get(HeaderIdempotencyKey) != ""
+}
+
+// NewIdempotencyKey generates a random (version 4) UUID,
+// meant to be used as Config.IdempotencyKeyGenerator
+func NewIdempotencyKey() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand only fails when the os has no entropy source
+		panic(fmt.Sprintf("httpclient: failed generating idempotency key: %v", err))
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant RFC 4122
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// withIdempotencyKey is a helper to attach a generated idempotency key to non-idempotent request,
+// the key is attached once per logical call so every retry attempt carries the same key
+func (hc *Client) withIdempotencyKey(req *http.Request) *http.Request {
+	if hc.config.IdempotencyKeyGenerator == nil || idempotentMethods[req.Method] || req.Header.Get(HeaderIdempotencyKey) != "" {
+		return req
+	}
+
+	// cloning so caller's request is left untouched
+	req = req.Clone(req.Context())
+	req.Header.Set(HeaderIdempotencyKey, hc.config.IdempotencyKeyGenerator())
+	return req
+}
@@ -0,0 +1,143 @@
+package httpclient
+
+import (
+	"net/http"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewIdempotencyKey(t *testing.T) {
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	key := NewIdempotencyKey()
+	assert.Regexp(t, uuid, key)
+	assert.NotEqual(t, key, NewIdempotencyKey())
+}
+
+func Test_Post_NotRetried_WithoutIdempotencyKey(t *testing.T) {
+	var keys []string
+	mu := &sync.Mutex{}
+	server := createConfiguredTestServer(testServerConfig{
+		status: http.StatusServiceUnavailable,
+		record: func(r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			keys = append(keys, r.Header.Get(HeaderIdempotencyKey))
+		},
+	})
+	defer server.Close()
+
+	client := NewHttpClient(Config{
+		Host:        server.URL,
+		RetryCount:  2,
+		RetryPolicy: ConstantRetryPolicy{Delay: time.Millisecond},
+	})
+
+	response, err := client.Post(Parameter{Path: "/ping", Body: "some-body"})
+	require.Error(t, err)
+	response.Body.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{""}, keys)
+}
+
+func Test_Post_Retried_WithGeneratedIdempotencyKey(t *testing.T) {
+	var keys []string
+	mu := &sync.Mutex{}
+	server := createConfiguredTestServer(testServerConfig{
+		status: http.StatusServiceUnavailable,
+		record: func(r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			keys = append(keys, r.Header.Get(HeaderIdempotencyKey))
+		},
+	})
+	defer server.Close()
+
+	client := NewHttpClient(Config{
+		Host:                    server.URL,
+		RetryCount:              2,
+		RetryPolicy:             ConstantRetryPolicy{Delay: time.Millisecond},
+		IdempotencyKeyGenerator: NewIdempotencyKey,
+	})
+
+	response, err := client.Post(Parameter{Path: "/ping", Body: "some-body"})
+	require.Error(t, err)
+	response.Body.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, keys, 3)
+	assert.NotEmpty(t, keys[0])
+	assert.Equal(t, keys[0], keys[1])
+	assert.Equal(t, keys[0], keys[2])
+}
+
+func Test_Post_Retried_WithCallerIdempotencyKey(t *testing.T) {
+	var keys []string
+	mu := &sync.Mutex{}
+	server := createConfiguredTestServer(testServerConfig{
+		status: http.StatusServiceUnavailable,
+		record: func(r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			keys = append(keys, r.Header.Get(HeaderIdempotencyKey))
+		},
+	})
+	defer server.Close()
+
+	client := NewHttpClient(Config{
+		Host:        server.URL,
+		RetryCount:  1,
+		RetryPolicy: ConstantRetryPolicy{Delay: time.Millisecond},
+		IdempotencyKeyGenerator: func() string {
+			return "generated-key"
+		},
+	})
+
+	response, err := client.Post(Parameter{
+		Path:   "/ping",
+		Header: map[string]string{HeaderIdempotencyKey: "caller-key"},
+	})
+	require.Error(t, err)
+	response.Body.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"caller-key", "caller-key"}, keys)
+}
+
+func Test_Get_IdempotencyKey_NotGenerated(t *testing.T) {
+	var keys []string
+	mu := &sync.Mutex{}
+	server := createConfiguredTestServer(testServerConfig{
+		status: http.StatusServiceUnavailable,
+		record: func(r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			keys = append(keys, r.Header.Get(HeaderIdempotencyKey))
+		},
+	})
+	defer server.Close()
+
+	client := NewHttpClient(Config{
+		Host:                    server.URL,
+		RetryCount:              1,
+		RetryPolicy:             ConstantRetryPolicy{Delay: time.Millisecond},
+		IdempotencyKeyGenerator: NewIdempotencyKey,
+	})
+
+	response, err := client.Get(Parameter{Path: "/ping"})
+	require.Error(t, err)
+	response.Body.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"", ""}, keys)
+}
@@ -0,0 +1,145 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Logger is a leveled key/value logger, keyvals are alternating keys and values, e.g.
+//
+//	logger.Info("circuit closed", "command", "http://localhost:3002")
+//
+// adapt slog, zap, logrus and the like to plug them in
+type Logger interface {
+	Debug(msg string, keyvals ...interface{})
+	Info(msg string, keyvals ...interface{})
+	Warn(msg string, keyvals ...interface{})
+	Error(msg string, keyvals ...interface{})
+}
+
+// Level is the severity of a log entry
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String implements fmt.Stringer
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return "LEVEL(" + strconv.Itoa(int(l)) + ")"
+}
+
+// LevelEnabler is optionally implemented by Logger to tell whether entries of a level are written,
+// sparing the cost of building entries which would be discarded anyway
+type LevelEnabler interface {
+	Enabled(level Level) bool
+}
+
+// NopLogger discards every entry, it is the default Config.Logger
+type NopLogger struct{}
+
+// Enabled implements LevelEnabler
+func (NopLogger) Enabled(level Level) bool { return false }
+
+func (NopLogger) Debug(msg string, keyvals ...interface{}) {}
+func (NopLogger) Info(msg string, keyvals ...interface{})  {}
+func (NopLogger) Warn(msg string, keyvals ...interface{})  {}
+func (NopLogger) Error(msg string, keyvals ...interface{}) {}
+
+// StdLogger writes entries at or above its level as logfmt lines, e.g.
+//
+//	time=2021-10-01T10:00:00.000+07:00 level=WARN msg="circuit opened" command=http://localhost:3002
+type StdLogger struct {
+	mutex *sync.Mutex
+	out   io.Writer
+	level Level
+}
+
+// NewStdLogger initiates StdLogger writing into out
+func NewStdLogger(out io.Writer, level Level) *StdLogger {
+	return &StdLogger{
+		mutex: &sync.Mutex{},
+		out:   out,
+		level: level,
+	}
+}
+
+// Enabled implements LevelEnabler
+func (l *StdLogger) Enabled(level Level) bool { return level >= l.level }
+
+func (l *StdLogger) Debug(msg string, keyvals ...interface{}) { l.log(LevelDebug, msg, keyvals) }
+func (l *StdLogger) Info(msg string, keyvals ...interface{})  { l.log(LevelInfo, msg, keyvals) }
+func (l *StdLogger) Warn(msg string, keyvals ...interface{})  { l.log(LevelWarn, msg, keyvals) }
+func (l *StdLogger) Error(msg string, keyvals ...interface{}) { l.log(LevelError, msg, keyvals) }
+
+// log is a helper to format and write an entry
+func (l *StdLogger) log(level Level, msg string, keyvals []interface{}) {
+	if level < l.level {
+		return
+	}
+
+	line := &strings.Builder{}
+	line.WriteString("time=" + time.Now().Format("2006-01-02T15:04:05.000Z07:00"))
+	line.WriteString(" level=" + level.String())
+	line.WriteString(" msg=" + formatLogValue(msg))
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		var value interface{} = "(MISSING)"
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+		line.WriteString(" " + key + "=" + formatLogValue(value))
+	}
+	line.WriteString("\n")
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	io.WriteString(l.out, line.String())
+}
+
+// formatLogValue is a helper to format a logfmt value,
+// structured values are written as json, values with spaces or quotes are quoted
+func formatLogValue(value interface{}) string {
+	var formatted string
+	switch v := value.(type) {
+	case string:
+		formatted = v
+	case error:
+		formatted = v.Error()
+	case time.Duration, fmt.Stringer:
+		formatted = fmt.Sprint(v)
+	case map[string]interface{}, map[string][]string, http.Header, map[string]string, []interface{}, []string:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			formatted = fmt.Sprint(v)
+			break
+		}
+		formatted = string(encoded)
+	default:
+		formatted = fmt.Sprint(v)
+	}
+
+	if formatted == "" || strings.ContainsAny(formatted, " =\"\t\n") {
+		return strconv.Quote(formatted)
+	}
+	return formatted
+}
@@ -0,0 +1,109 @@
+package httpclient
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// logEntry is an entry recorded by recordingLogger
+type logEntry struct {
+	level   Level
+	msg     string
+	keyvals map[string]interface{}
+}
+
+// recordingLogger is a Logger recording its entries
+type recordingLogger struct {
+	mutex   *sync.Mutex
+	entries []logEntry
+}
+
+// this is just a helper
+func createRecordingLogger() *recordingLogger {
+	return &recordingLogger{mutex: &sync.Mutex{}}
+}
+
+func (l *recordingLogger) Debug(msg string, keyvals ...interface{}) { l.log(LevelDebug, msg, keyvals) }
+func (l *recordingLogger) Info(msg string, keyvals ...interface{})  { l.log(LevelInfo, msg, keyvals) }
+func (l *recordingLogger) Warn(msg string, keyvals ...interface{})  { l.log(LevelWarn, msg, keyvals) }
+func (l *recordingLogger) Error(msg string, keyvals ...interface{}) { l.log(LevelError, msg, keyvals) }
+
+func (l *recordingLogger) log(level Level, msg string, keyvals []interface{}) {
+	entry := logEntry{level: level, msg: msg, keyvals: map[string]interface{}{}}
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		entry.keyvals[keyvals[i].(string)] = keyvals[i+1]
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.entries = append(l.entries, entry)
+}
+
+// find returns entries with designated message
+func (l *recordingLogger) find(msg string) []logEntry {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	found := []logEntry{}
+	for _, entry := range l.entries {
+		if entry.msg == msg {
+			found = append(found, entry)
+		}
+	}
+	return found
+}
+
+func Test_StdLogger(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := NewStdLogger(out, LevelInfo)
+
+	logger.Debug("hidden")
+	logger.Info("circuit closed", "command", "http://localhost:3002", "attempt", 2)
+	logger.Error("failed", "error", errors.New("some error"), "duration", 1500*time.Millisecond, "dangling")
+	logger.Warn("headers", "header", http.Header{"Accept": {"a", "b"}})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Regexp(t, `^time=\S+ level=INFO msg="circuit closed" command=http://localhost:3002 attempt=2$`, lines[0])
+	assert.Regexp(t, `^time=\S+ level=ERROR msg=failed error="some error" duration=1.5s dangling=\(MISSING\)$`, lines[1])
+	assert.Regexp(t, `^time=\S+ level=WARN msg=headers header="{\\"Accept\\":\[\\"a\\",\\"b\\"\]}"$`, lines[2])
+}
+
+func Test_Level_String(t *testing.T) {
+	assert.Equal(t, "DEBUG", LevelDebug.String())
+	assert.Equal(t, "WARN", LevelWarn.String())
+	assert.Equal(t, "LEVEL(9)", Level(9).String())
+}
+
+func Test_Logger_LogsEvents(t *testing.T) {
+	var hits int32
+	server := createConfiguredTestServer(testServerConfig{status: http.StatusServiceUnavailable, hits: &hits})
+	defer server.Close()
+
+	logger := createRecordingLogger()
+	client := NewHttpClient(Config{
+		Name:        "zulu",
+		Host:        server.URL,
+		RetryCount:  1,
+		RetryPolicy: ConstantRetryPolicy{Delay: time.Millisecond},
+		Logger:      logger,
+	})
+
+	response, err := client.Get(Parameter{Path: "/ping"})
+	assert.Error(t, err)
+	response.Body.Close()
+
+	retries := logger.find("retrying request")
+	if assert.Len(t, retries, 1) {
+		assert.Equal(t, LevelWarn, retries[0].level)
+		assert.Equal(t, "zulu", retries[0].keyvals["client"])
+		assert.Equal(t, 1, retries[0].keyvals["attempt"])
+	}
+}
@@ -0,0 +1,84 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	metricCollector "github.com/afex/hystrix-go/hystrix/metric_collector"
+)
+
+// MetricsCollector records measurements of the client, implemented by metrics backends,
+// e.g. subpackage promcollector for Prometheus.
+// methods are called synchronously on the request's goroutine, they must not block
+type MetricsCollector interface {
+	// ObserveRequest records an http attempt which has reached the wire, with its latency
+	ObserveRequest(labels MetricLabels, duration time.Duration)
+
+	// IncRetry records a failing request about to be retried
+	IncRetry(labels MetricLabels)
+
+	// IncFallback records fallback replacing the outcome of a failing call
+	IncFallback(labels MetricLabels)
+
+	// IncShortCircuit records an attempt rejected by an open circuit
+	IncShortCircuit(labels MetricLabels)
+
+	// IncTimeout records an attempt given up by circuit breaker's timeout
+	IncTimeout(labels MetricLabels)
+
+	// SetCircuitState records state of a circuit
+	SetCircuitState(client, commandKey string, state CircuitState)
+}
+
+// MetricLabels are the low-cardinality dimensions of a measurement
+type MetricLabels struct {
+	Client      string // Config.Name
+	Method      string
+	Route       string // route template, empty when request is not executed via DoContext
+	StatusClass string // e.g. "2xx", "5xx", or "error" when no response is received, empty when not applicable
+}
+
+// StatusClassError is the status class of an attempt failing without response
+const StatusClassError = "error"
+
+// CircuitCollectorFactory creates hystrix metric collector of a circuit used by the client,
+// e.g. StatsD or DogStatsD collectors of subpackage statsdcollector.
+// it is called once per client and command key, before the client's first execution on the circuit.
+// circuits are shared by clients of the same command key, the collector replaces the one of a previous client
+type CircuitCollectorFactory func(circuit CircuitInfo) metricCollector.MetricCollector
+
+// CircuitInfo describes a circuit to CircuitCollectorFactory
+type CircuitInfo struct {
+	CommandKey string
+	Client     string // Config.Name
+	Route      string // route template, empty unless circuits are per route, i.e. CommandNamerByRoute
+}
+
+// nopMetricsCollector is the default MetricsCollector, recording nothing
+type nopMetricsCollector struct{}
+
+func (nopMetricsCollector) ObserveRequest(labels MetricLabels, duration time.Duration)    {}
+func (nopMetricsCollector) IncRetry(labels MetricLabels)                                  {}
+func (nopMetricsCollector) IncFallback(labels MetricLabels)                               {}
+func (nopMetricsCollector) IncShortCircuit(labels MetricLabels)                           {}
+func (nopMetricsCollector) IncTimeout(labels MetricLabels)                                {}
+func (nopMetricsCollector) SetCircuitState(client, commandKey string, state CircuitState) {}
+
+// statusClass is a helper to group status code, e.g. 404 into "4xx"
+func statusClass(res *http.Response) string {
+	if res == nil {
+		return StatusClassError
+	}
+	return fmt.Sprintf("%dxx", res.StatusCode/100)
+}
+
+// metricLabels is a helper to build labels of a request
+func (hc *Client) metricLabels(req *http.Request) MetricLabels {
+	route, _ := RouteTemplate(req.Context())
+	return MetricLabels{
+		Client: hc.config.Name,
+		Method: req.Method,
+		Route:  route,
+	}
+}
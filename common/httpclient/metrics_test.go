@@ -0,0 +1,125 @@
+package httpclient
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	metricCollector "github.com/afex/hystrix-go/hystrix/metric_collector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingCircuitCollector is a hystrix metric collector recording its updates
+type recordingCircuitCollector struct {
+	mutex    *sync.Mutex
+	updates  int
+	isClosed bool
+}
+
+func (c *recordingCircuitCollector) Update(r metricCollector.MetricResult) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.updates++
+}
+
+func (c *recordingCircuitCollector) Reset() {}
+
+func (c *recordingCircuitCollector) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.isClosed = true
+	return nil
+}
+
+func (c *recordingCircuitCollector) closed() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.isClosed
+}
+
+func (c *recordingCircuitCollector) count() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.updates
+}
+
+func Test_CircuitCollector(t *testing.T) {
+	testCases := map[string]struct {
+		commandNamer  CommandNamer
+		expectedRoute string
+	}{
+		"per route": {commandNamer: CommandNamerByRoute(), expectedRoute: "/circuit-collector/{id}"},
+		"per name":  {commandNamer: CommandNamerByName(createCommandName(t)), expectedRoute: ""},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var hits int32
+			server := createConfiguredTestServer(testServerConfig{hits: &hits})
+			defer server.Close()
+
+			infos := []CircuitInfo{}
+			collector := &recordingCircuitCollector{mutex: &sync.Mutex{}}
+			client := NewHttpClient(Config{
+				Name:                  "zulu",
+				Host:                  server.URL,
+				IsUsingCircuitBreaker: true,
+				CommandNamer:          testCase.commandNamer,
+				CircuitCollector: func(circuit CircuitInfo) metricCollector.MetricCollector {
+					infos = append(infos, circuit)
+					return collector
+				},
+			})
+
+			for i := 0; i < 2; i++ {
+				response, err := client.Get(Parameter{
+					Path:       "/circuit-collector/{id}",
+					PathParams: map[string]string{"id": "1"},
+				})
+				require.NoError(t, err)
+				response.Body.Close()
+			}
+
+			require.Len(t, infos, 1)
+			assert.Equal(t, "zulu", infos[0].Client)
+			assert.Equal(t, testCase.expectedRoute, infos[0].Route)
+			assert.NotEmpty(t, infos[0].CommandKey)
+			require.Eventually(t, func() bool {
+				return collector.count() == 2
+			}, time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+func Test_CircuitCollector_LatestClientWins(t *testing.T) {
+	var hits int32
+	server := createConfiguredTestServer(testServerConfig{hits: &hits})
+	defer server.Close()
+
+	commandName := createCommandName(t)
+	collectors := []*recordingCircuitCollector{}
+	for i := 0; i < 2; i++ {
+		collector := &recordingCircuitCollector{mutex: &sync.Mutex{}}
+		collectors = append(collectors, collector)
+
+		client := NewHttpClient(Config{
+			Host:                  server.URL,
+			IsUsingCircuitBreaker: true,
+			CommandNamer:          CommandNamerByName(commandName),
+			CircuitCollector: func(circuit CircuitInfo) metricCollector.MetricCollector {
+				return collector
+			},
+		})
+
+		response, err := client.Get(Parameter{Path: "/ping"})
+		require.NoError(t, err)
+		response.Body.Close()
+	}
+
+	require.Eventually(t, func() bool {
+		return collectors[1].count() >= 1
+	}, time.Second, 10*time.Millisecond)
+	assert.True(t, collectors[0].closed())
+	assert.False(t, collectors[1].closed())
+}
@@ -0,0 +1,53 @@
+package httpclient
+
+import "net/http"
+
+// Doer executes an http request
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// DoerFunc is an adapter to allow the use of ordinary functions as Doer
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+// Do implements Doer
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps a Doer with additional behaviour, e.g. auth, logging, metrics, header injection
+type Middleware func(next Doer) Doer
+
+// Chain wraps doer with middlewares,
+// the first middleware is the outermost one, hence executed first
+func Chain(doer Doer, middlewares ...Middleware) Doer {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		doer = middlewares[i](doer)
+	}
+	return doer
+}
+
+// chain is a helper to compose the client's full execution chain:
+//
+//	CallMiddlewares -> idempotency key -> response fallback -> retry -> hedging -> circuit breaker -> AttemptMiddlewares -> request log -> send
+func (hc *Client) chain() Doer {
+	var middlewares []Middleware
+	middlewares = append(middlewares, hc.config.CallMiddlewares...)
+	middlewares = append(middlewares, hc.idempotencyMiddleware)
+	if hc.config.IsUsingCircuitBreaker && hc.config.CbConfig.ResponseFallback != nil {
+		middlewares = append(middlewares, hc.responseFallbackMiddleware)
+	}
+	middlewares = append(middlewares, hc.retryMiddleware)
+	if hc.config.Hedging != nil {
+		middlewares = append(middlewares, hc.hedgingMiddleware)
+	}
+	if hc.config.IsUsingCircuitBreaker {
+		middlewares = append(middlewares, hc.circuitBreakerMiddleware)
+	}
+	middlewares = append(middlewares, hc.config.AttemptMiddlewares...)
+	if hc.config.RequestLog != nil {
+		middlewares = append(middlewares, hc.requestLogMiddleware)
+	}
+
+	return Chain(DoerFunc(hc.send), middlewares...)
+}
@@ -0,0 +1,98 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// this is just a helper, middleware that records its name on every execution
+func createRecordingMiddleware(name string, records *[]string, mu *sync.Mutex) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			*records = append(*records, name)
+			mu.Unlock()
+			return next.Do(req)
+		})
+	}
+}
+
+func Test_Chain_Order(t *testing.T) {
+	var records []string
+	mu := &sync.Mutex{}
+
+	doer := Chain(DoerFunc(func(req *http.Request) (*http.Response, error) {
+		records = append(records, "doer")
+		return nil, nil
+	}),
+		createRecordingMiddleware("first", &records, mu),
+		createRecordingMiddleware("second", &records, mu),
+	)
+
+	_, err := doer.Do(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second", "doer"}, records)
+}
+
+func Test_Middlewares_CallAndAttempt(t *testing.T) {
+	var hits int32
+	server := createConfiguredTestServer(testServerConfig{status: http.StatusServiceUnavailable, hits: &hits})
+	defer server.Close()
+
+	var records []string
+	mu := &sync.Mutex{}
+	client := NewHttpClient(Config{
+		Host:                  server.URL,
+		RetryCount:            2,
+		RetryPolicy:           ConstantRetryPolicy{Delay: time.Millisecond},
+		IsUsingCircuitBreaker: true,
+		CommandNamer:          CommandNamerByName(createCommandName(t)),
+		CallMiddlewares: []Middleware{
+			createRecordingMiddleware("call", &records, mu),
+		},
+		AttemptMiddlewares: []Middleware{
+			createRecordingMiddleware("attempt", &records, mu),
+		},
+	})
+
+	response, err := client.Get(Parameter{Path: "/ping"})
+	require.Error(t, err)
+	response.Body.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"call", "attempt", "attempt", "attempt"}, records)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+}
+
+func Test_AttemptMiddleware_HeaderInjection(t *testing.T) {
+	var header atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header.Store(r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	client := NewHttpClient(Config{
+		Host: server.URL,
+		AttemptMiddlewares: []Middleware{
+			func(next Doer) Doer {
+				return DoerFunc(func(req *http.Request) (*http.Response, error) {
+					req.Header.Set("Authorization", "Bearer some-token")
+					return next.Do(req)
+				})
+			},
+		},
+	})
+
+	response, err := client.Get(Parameter{Path: "/ping"})
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, "Bearer some-token", header.Load())
+}
@@ -38,6 +38,29 @@ func (_m *HttpClient) Delete(param httpclient.Parameter) (*http.Response, error)
 	return r0, r1
 }
 
+// DeleteInto provides a mock function with given fields: ctx, param, out
+func (_m *HttpClient) DeleteInto(ctx context.Context, param httpclient.Parameter, out interface{}) (*http.Response, error) {
+	ret := _m.Called(ctx, param, out)
+
+	var r0 *http.Response
+	if rf, ok := ret.Get(0).(func(context.Context, httpclient.Parameter, interface{}) *http.Response); ok {
+		r0 = rf(ctx, param, out)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*http.Response)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, httpclient.Parameter, interface{}) error); ok {
+		r1 = rf(ctx, param, out)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteWithContext provides a mock function with given fields: ctx, param
 func (_m *HttpClient) DeleteWithContext(ctx context.Context, param httpclient.Parameter) (*http.Response, error) {
 	ret := _m.Called(ctx, param)
@@ -107,6 +130,29 @@ func (_m *HttpClient) DoContext(ctx context.Context, httpMethod string, param ht
 	return r0, r1
 }
 
+// DoInto provides a mock function with given fields: ctx, httpMethod, param, out
+func (_m *HttpClient) DoInto(ctx context.Context, httpMethod string, param httpclient.Parameter, out interface{}) (*http.Response, error) {
+	ret := _m.Called(ctx, httpMethod, param, out)
+
+	var r0 *http.Response
+	if rf, ok := ret.Get(0).(func(context.Context, string, httpclient.Parameter, interface{}) *http.Response); ok {
+		r0 = rf(ctx, httpMethod, param, out)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*http.Response)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, httpclient.Parameter, interface{}) error); ok {
+		r1 = rf(ctx, httpMethod, param, out)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DoVanilla provides a mock function with given fields: req
 func (_m *HttpClient) DoVanilla(req *http.Request) (*http.Response, error) {
 	ret := _m.Called(req)
@@ -153,6 +199,29 @@ func (_m *HttpClient) Get(param httpclient.Parameter) (*http.Response, error) {
 	return r0, r1
 }
 
+// GetInto provides a mock function with given fields: ctx, param, out
+func (_m *HttpClient) GetInto(ctx context.Context, param httpclient.Parameter, out interface{}) (*http.Response, error) {
+	ret := _m.Called(ctx, param, out)
+
+	var r0 *http.Response
+	if rf, ok := ret.Get(0).(func(context.Context, httpclient.Parameter, interface{}) *http.Response); ok {
+		r0 = rf(ctx, param, out)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*http.Response)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, httpclient.Parameter, interface{}) error); ok {
+		r1 = rf(ctx, param, out)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetWithContext provides a mock function with given fields: ctx, param
 func (_m *HttpClient) GetWithContext(ctx context.Context, param httpclient.Parameter) (*http.Response, error) {
 	ret := _m.Called(ctx, param)
@@ -199,6 +268,29 @@ func (_m *HttpClient) Post(param httpclient.Parameter) (*http.Response, error) {
 	return r0, r1
 }
 
+// PostInto provides a mock function with given fields: ctx, param, out
+func (_m *HttpClient) PostInto(ctx context.Context, param httpclient.Parameter, out interface{}) (*http.Response, error) {
+	ret := _m.Called(ctx, param, out)
+
+	var r0 *http.Response
+	if rf, ok := ret.Get(0).(func(context.Context, httpclient.Parameter, interface{}) *http.Response); ok {
+		r0 = rf(ctx, param, out)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*http.Response)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, httpclient.Parameter, interface{}) error); ok {
+		r1 = rf(ctx, param, out)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // PostWithContext provides a mock function with given fields: ctx, param
 func (_m *HttpClient) PostWithContext(ctx context.Context, param httpclient.Parameter) (*http.Response, error) {
 	ret := _m.Called(ctx, param)
@@ -245,6 +337,29 @@ func (_m *HttpClient) Put(param httpclient.Parameter) (*http.Response, error) {
 	return r0, r1
 }
 
+// PutInto provides a mock function with given fields: ctx, param, out
+func (_m *HttpClient) PutInto(ctx context.Context, param httpclient.Parameter, out interface{}) (*http.Response, error) {
+	ret := _m.Called(ctx, param, out)
+
+	var r0 *http.Response
+	if rf, ok := ret.Get(0).(func(context.Context, httpclient.Parameter, interface{}) *http.Response); ok {
+		r0 = rf(ctx, param, out)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*http.Response)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, httpclient.Parameter, interface{}) error); ok {
+		r1 = rf(ctx, param, out)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // PutWithContext provides a mock function with given fields: ctx, param
 func (_m *HttpClient) PutWithContext(ctx context.Context, param httpclient.Parameter) (*http.Response, error) {
 	ret := _m.Called(ctx, param)
@@ -0,0 +1,187 @@
+package httpclient
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// ContentTypeMultipart is the content type of Multipart body
+const ContentTypeMultipart = "multipart/form-data"
+
+// Multipart is a multipart/form-data Parameter.Body,
+// it is streamed to the wire part by part without buffering the files into memory
+type Multipart struct {
+	Fields map[string]string // plain form fields
+	Files  []MultipartFile   // file parts
+}
+
+// MultipartFile is a single file part of Multipart.
+// the content is taken from Path, Open or Reader, whichever is defined first.
+// only Path and Open are re-openable, Reader can only be sent once thus the request is not retried
+type MultipartFile struct {
+	FieldName   string                        // form field name
+	FileName    string                        // default base name of Path
+	ContentType string                        // default guessed from file name's extension, falling back to application/octet-stream
+	Path        string                        // file path, opened on every attempt
+	Open        func() (io.ReadCloser, error) // file opener, called on every attempt
+	Reader      io.Reader                     // one-shot file content
+}
+
+// asMultipart is a helper to check whether Parameter.Body is a multipart body
+func asMultipart(body interface{}) (*Multipart, bool) {
+	switch m := body.(type) {
+	case Multipart:
+		return &m, true
+	case *Multipart:
+		return m, m != nil
+	}
+	return nil, false
+}
+
+// isReopenable is a helper to check whether every file part can be re-opened for retry
+func (m *Multipart) isReopenable() bool {
+	for _, file := range m.Files {
+		if file.Path == "" && file.Open == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// write is a helper to stream the multipart content into w
+func (m *Multipart) write(w io.Writer, boundary string) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return err
+	}
+
+	// sorted so the payload is deterministic
+	keys := make([]string, 0, len(m.Fields))
+	for key := range m.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := mw.WriteField(key, m.Fields[key]); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range m.Files {
+		if err := file.write(mw); err != nil {
+			return err
+		}
+	}
+
+	return mw.Close()
+}
+
+// write is a helper to stream a single file part into mw
+func (f MultipartFile) write(mw *multipart.Writer) error {
+	content, err := f.open()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	fileName := f.FileName
+	if fileName == "" && f.Path != "" {
+		fileName = filepath.Base(f.Path)
+	}
+
+	contentType := f.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(fileName))
+	}
+	if contentType == "" {
+		contentType = ContentTypeOctetStream
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{
+		"name":     f.FieldName,
+		"filename": fileName,
+	}))
+	header.Set("Content-Type", contentType)
+
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(part, content)
+	return err
+}
+
+// open is a helper to open the file part's content
+func (f MultipartFile) open() (io.ReadCloser, error) {
+	switch {
+	case f.Path != "":
+		return os.Open(f.Path)
+	case f.Open != nil:
+		return f.Open()
+	case f.Reader != nil:
+		return ioutil.NopCloser(f.Reader), nil
+	}
+	return nil, fmt.Errorf("httpclient: multipart file %q has no content", f.FieldName)
+}
+
+// setMultipartBody is a helper to attach streamed multipart body to the request,
+// GetBody is only defined when every part is re-openable
+func setMultipartBody(req *http.Request, m *Multipart) {
+	boundary := multipart.NewWriter(nil).Boundary()
+	newBody := func() (io.ReadCloser, error) {
+		return newPipeBody(func(w io.Writer) error {
+			return m.write(w, boundary)
+		}), nil
+	}
+
+	req.Body, _ = newBody()
+	req.ContentLength = -1
+	req.GetBody = nil
+	if m.isReopenable() {
+		req.GetBody = newBody
+	}
+
+	req.Header.Set("Content-Type", mime.FormatMediaType(ContentTypeMultipart, map[string]string{"boundary": boundary}))
+}
+
+// pipeBody is a request body fed by a writer function through io.Pipe,
+// the writer only starts on the first read so an unsent body, e.g. rejected by circuit breaker, leaks no goroutine
+type pipeBody struct {
+	once  sync.Once
+	write func(w io.Writer) error
+	pr    *io.PipeReader
+	pw    *io.PipeWriter
+}
+
+// newPipeBody initialises pipeBody
+func newPipeBody(write func(w io.Writer) error) *pipeBody {
+	pr, pw := io.Pipe()
+	return &pipeBody{write: write, pr: pr, pw: pw}
+}
+
+// Read implements io.Reader
+func (b *pipeBody) Read(p []byte) (int, error) {
+	b.once.Do(func() {
+		go func() {
+			b.pw.CloseWithError(b.write(b.pw))
+		}()
+	})
+	return b.pr.Read(p)
+}
+
+// Close implements io.Closer, stopping the writer
+func (b *pipeBody) Close() error {
+	return b.pr.Close()
+}
@@ -0,0 +1,186 @@
+package httpclient
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receivedMultipart is what the multipart test server received on a single request
+type receivedMultipart struct {
+	chunked      bool
+	fields       map[string]string
+	files        map[string]string
+	fileNames    map[string]string
+	contentTypes map[string]string
+}
+
+// recordMultipart is a helper to parse multipart form of every request received by the test server
+func recordMultipart(received *[]receivedMultipart, mu *sync.Mutex) func(r *http.Request) {
+	return func(r *http.Request) {
+		rec := receivedMultipart{
+			chunked:      r.ContentLength == -1,
+			fields:       map[string]string{},
+			files:        map[string]string{},
+			fileNames:    map[string]string{},
+			contentTypes: map[string]string{},
+		}
+
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			return
+		}
+		for key := range r.MultipartForm.Value {
+			rec.fields[key] = r.MultipartForm.Value[key][0]
+		}
+		for key, headers := range r.MultipartForm.File {
+			f, _ := headers[0].Open()
+			b, _ := ioutil.ReadAll(f)
+			f.Close()
+			rec.files[key] = string(b)
+			rec.fileNames[key] = headers[0].Filename
+			rec.contentTypes[key] = headers[0].Header.Get("Content-Type")
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		*received = append(*received, rec)
+	}
+}
+
+// this is just a helper
+func createTempFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func Test_Post_Multipart(t *testing.T) {
+	var received []receivedMultipart
+	mu := &sync.Mutex{}
+	server := createConfiguredTestServer(testServerConfig{record: recordMultipart(&received, mu)})
+	defer server.Close()
+
+	client := NewHttpClient(Config{Host: server.URL})
+
+	response, err := client.Post(Parameter{
+		Path: "/upload",
+		Body: &Multipart{
+			Fields: map[string]string{"someField": "some-value"},
+			Files: []MultipartFile{
+				{FieldName: "fromPath", Path: createTempFile(t, "some-file.txt", "content-from-path")},
+				{FieldName: "fromReader", FileName: "some-file.bin", Reader: strings.NewReader("content-from-reader")},
+				{FieldName: "fromOpen", FileName: "some-file.json", ContentType: ContentTypeJSON, Open: func() (io.ReadCloser, error) {
+					return ioutil.NopCloser(strings.NewReader(`{}`)), nil
+				}},
+			},
+		},
+	})
+	require.NoError(t, err)
+	response.Body.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received, 1)
+
+	rec := received[0]
+	assert.True(t, rec.chunked, "multipart body should be streamed")
+	assert.Equal(t, map[string]string{"someField": "some-value"}, rec.fields)
+	assert.Equal(t, map[string]string{
+		"fromPath":   "content-from-path",
+		"fromReader": "content-from-reader",
+		"fromOpen":   `{}`,
+	}, rec.files)
+	assert.Equal(t, "some-file.txt", rec.fileNames["fromPath"])
+	assert.True(t, strings.HasPrefix(rec.contentTypes["fromPath"], "text/plain"))
+	assert.Equal(t, ContentTypeOctetStream, rec.contentTypes["fromReader"])
+	assert.Equal(t, ContentTypeJSON, rec.contentTypes["fromOpen"])
+}
+
+func Test_Put_Multipart_Retry_Reopenable(t *testing.T) {
+	var received []receivedMultipart
+	mu := &sync.Mutex{}
+	server := createConfiguredTestServer(testServerConfig{failures: 1, record: recordMultipart(&received, mu)})
+	defer server.Close()
+
+	client := NewHttpClient(Config{
+		Host:        server.URL,
+		RetryCount:  1,
+		RetryPolicy: ConstantRetryPolicy{Delay: time.Millisecond},
+	})
+
+	response, err := client.Put(Parameter{
+		Path: "/upload",
+		Body: Multipart{
+			Files: []MultipartFile{
+				{FieldName: "file", Path: createTempFile(t, "some-file.txt", "some-content")},
+			},
+		},
+	})
+	require.NoError(t, err)
+	response.Body.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received, 2)
+	assert.Equal(t, received[0].files, received[1].files)
+	assert.Equal(t, "some-content", received[1].files["file"])
+}
+
+func Test_Put_Multipart_Retry_NotReopenable(t *testing.T) {
+	var received []receivedMultipart
+	mu := &sync.Mutex{}
+	server := createConfiguredTestServer(testServerConfig{failures: 1, record: recordMultipart(&received, mu)})
+	defer server.Close()
+
+	client := NewHttpClient(Config{
+		Host:        server.URL,
+		RetryCount:  1,
+		RetryPolicy: ConstantRetryPolicy{Delay: time.Millisecond},
+	})
+
+	response, err := client.Put(Parameter{
+		Path: "/upload",
+		Body: &Multipart{
+			Files: []MultipartFile{
+				{FieldName: "file", Reader: strings.NewReader("some-content")},
+			},
+		},
+	})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrBodyNotReplayable))
+	response.Body.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, received, 1)
+}
+
+func Test_Post_Multipart_Failed_OpeningFile(t *testing.T) {
+	var received []receivedMultipart
+	mu := &sync.Mutex{}
+	server := createConfiguredTestServer(testServerConfig{record: recordMultipart(&received, mu)})
+	defer server.Close()
+
+	client := NewHttpClient(Config{Host: server.URL})
+
+	_, err := client.Post(Parameter{
+		Path: "/upload",
+		Body: &Multipart{
+			Files: []MultipartFile{
+				{FieldName: "file", Path: filepath.Join(t.TempDir(), "missing.txt")},
+			},
+		},
+	})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
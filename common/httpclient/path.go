@@ -0,0 +1,39 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// pathPlaceholder matches named path variable in route template, e.g. "{orderId}"
+var pathPlaceholder = regexp.MustCompile(`\{([^{}/]*)\}`)
+
+// resolvePath is a helper to fill named path variables of a route template,
+// e.g. "/users/{id}/orders/{orderId}", escaping every value as a single path segment
+func resolvePath(template string, pathParams map[string]string) (string, error) {
+	var errResolve error
+	path := pathPlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		name := strings.Trim(placeholder, "{}")
+		value, ok := pathParams[name]
+		if !ok {
+			if errResolve == nil {
+				errResolve = fmt.Errorf("httpclient: unresolved path variable %q in %q", name, template)
+			}
+			return placeholder
+		}
+		return url.PathEscape(value)
+	})
+
+	return path, errResolve
+}
+
+// escapePathVariables is a helper to join path variables as escaped path segments
+func escapePathVariables(pathVariables []string) string {
+	segments := make([]string, len(pathVariables))
+	for i, variable := range pathVariables {
+		segments[i] = url.PathEscape(variable)
+	}
+	return strings.Join(segments, "/")
+}
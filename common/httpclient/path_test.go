@@ -0,0 +1,79 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ResolvePath(t *testing.T) {
+	testCases := []struct {
+		template string
+		params   map[string]string
+		expected string
+	}{
+		{"/ping", nil, "/ping"},
+		{"/users/{id}", map[string]string{"id": "1"}, "/users/1"},
+		{"/users/{id}/orders/{orderId}", map[string]string{"id": "1", "orderId": "2"}, "/users/1/orders/2"},
+		{"/users/{id}", map[string]string{"id": "a/b?c#d"}, "/users/a%2Fb%3Fc%23d"},
+		{"/users/{id}", map[string]string{"id": "some name"}, "/users/some%20name"},
+	}
+
+	for _, tc := range testCases {
+		path, err := resolvePath(tc.template, tc.params)
+		require.NoError(t, err, tc.template)
+		assert.Equal(t, tc.expected, path, tc.template)
+	}
+}
+
+func Test_ResolvePath_Unresolved(t *testing.T) {
+	_, err := resolvePath("/users/{id}/orders/{orderId}", map[string]string{"id": "1"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"orderId"`)
+}
+
+func Test_EscapePathVariables(t *testing.T) {
+	assert.Equal(t, "a/b%2Fc/d%3F", escapePathVariables([]string{"a", "b/c", "d?"}))
+}
+
+func Test_Get_PathTemplate(t *testing.T) {
+	var escapedPath string
+	var route string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		escapedPath = r.URL.EscapedPath()
+	}))
+	defer server.Close()
+
+	client := NewHttpClient(Config{
+		Host: server.URL,
+		AttemptMiddlewares: []Middleware{
+			func(next Doer) Doer {
+				return DoerFunc(func(req *http.Request) (*http.Response, error) {
+					route, _ = RouteTemplate(req.Context())
+					return next.Do(req)
+				})
+			},
+		},
+	})
+
+	response, err := client.Get(Parameter{
+		Path:          "/users/{id}/orders/{orderId}",
+		PathParams:    map[string]string{"id": "a/b", "orderId": "1?"},
+		PathVariables: []string{"c/d"},
+	})
+	require.NoError(t, err)
+	response.Body.Close()
+
+	assert.Equal(t, "/users/a%2Fb/orders/1%3F/c%2Fd", escapedPath)
+	assert.Equal(t, "/users/{id}/orders/{orderId}", route)
+}
+
+func Test_Get_PathTemplate_Unresolved(t *testing.T) {
+	client := NewHttpClient(Config{Host: testHost})
+
+	_, err := client.Get(Parameter{Path: "/users/{id}"})
+	require.Error(t, err)
+}
@@ -0,0 +1,154 @@
+// Package promcollector records httpclient metrics into Prometheus,
+// kept apart so httpclient itself doesn't depend on Prometheus
+package promcollector
+
+import (
+	"strconv"
+	"time"
+
+	httpclient "playground/common/httpclient"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// circuit state gauge values, ordered by severity
+var circuitStateValues = map[httpclient.CircuitState]float64{
+	httpclient.CircuitClosed:   0,
+	httpclient.CircuitHalfOpen: 1,
+	httpclient.CircuitOpen:     2,
+}
+
+// label names of call metrics
+var callLabels = []string{"client", "method", "route"}
+
+// Collector is httpclient.MetricsCollector backed by Prometheus metrics,
+// a single Collector may be shared by many clients, they are told apart by "client" label
+type Collector struct {
+	requests      *prometheus.CounterVec
+	latency       *prometheus.HistogramVec
+	retries       *prometheus.CounterVec
+	fallbacks     *prometheus.CounterVec
+	shortCircuits *prometheus.CounterVec
+	timeouts      *prometheus.CounterVec
+	hedges        *prometheus.CounterVec
+	circuitState  *prometheus.GaugeVec
+	phases        *prometheus.HistogramVec
+	connections   *prometheus.CounterVec
+}
+
+// Options is the Collector configuration
+type Options struct {
+	Namespace string    // metric name prefix, e.g. "alpha" gives "alpha_httpclient_requests_total"
+	Buckets   []float64 // latency histogram buckets in seconds, default prometheus.DefBuckets
+}
+
+// NewCollector initiates Collector and registers its metrics into reg
+func NewCollector(reg prometheus.Registerer, opts Options) (*Collector, error) {
+	// set default value if not defined
+	if opts.Buckets == nil {
+		opts.Buckets = prometheus.DefBuckets
+	}
+
+	counter := func(name, help string, labels []string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Subsystem: "httpclient",
+			Name:      name,
+			Help:      help,
+		}, labels)
+	}
+
+	c := &Collector{
+		requests: counter("requests_total", "http attempts which have reached the wire", append(callLabels, "status_class")),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: opts.Namespace,
+			Subsystem: "httpclient",
+			Name:      "request_duration_seconds",
+			Help:      "latency of http attempts",
+			Buckets:   opts.Buckets,
+		}, append(callLabels, "status_class")),
+		retries:       counter("retries_total", "failing requests which have been retried", callLabels),
+		fallbacks:     counter("fallbacks_total", "failing calls whose outcome has been replaced by fallback", callLabels),
+		shortCircuits: counter("short_circuits_total", "attempts rejected by an open circuit", callLabels),
+		timeouts:      counter("timeouts_total", "attempts given up by circuit breaker's timeout", callLabels),
+		hedges:        counter("hedges_total", "hedges fired for slow attempts", callLabels),
+		circuitState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: opts.Namespace,
+			Subsystem: "httpclient",
+			Name:      "circuit_state",
+			Help:      "state of circuit, 0 closed, 1 half-open, 2 open",
+		}, []string{"client", "command"}),
+		phases: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: opts.Namespace,
+			Subsystem: "httpclient",
+			Name:      "request_phase_duration_seconds",
+			Help:      "latency of http attempts' phases, i.e. dns, connect, tls_handshake and ttfb",
+			Buckets:   opts.Buckets,
+		}, append(callLabels, "phase")),
+		connections: counter("connections_total", "connections used by http attempts, by whether they are reused", append(callLabels, "reused")),
+	}
+
+	collectors := []prometheus.Collector{
+		c.requests, c.latency, c.retries, c.fallbacks, c.shortCircuits, c.timeouts, c.hedges, c.circuitState, c.phases, c.connections,
+	}
+	for _, collector := range collectors {
+		if err := reg.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// ObserveRequest records an http attempt and its latency
+func (c *Collector) ObserveRequest(labels httpclient.MetricLabels, duration time.Duration) {
+	values := []string{labels.Client, labels.Method, labels.Route, labels.StatusClass}
+	c.requests.WithLabelValues(values...).Inc()
+	c.latency.WithLabelValues(values...).Observe(duration.Seconds())
+}
+
+// IncRetry records a retry
+func (c *Collector) IncRetry(labels httpclient.MetricLabels) {
+	c.retries.WithLabelValues(labels.Client, labels.Method, labels.Route).Inc()
+}
+
+// IncFallback records a fallback
+func (c *Collector) IncFallback(labels httpclient.MetricLabels) {
+	c.fallbacks.WithLabelValues(labels.Client, labels.Method, labels.Route).Inc()
+}
+
+// IncShortCircuit records a short-circuited attempt
+func (c *Collector) IncShortCircuit(labels httpclient.MetricLabels) {
+	c.shortCircuits.WithLabelValues(labels.Client, labels.Method, labels.Route).Inc()
+}
+
+// IncTimeout records a timed out attempt
+func (c *Collector) IncTimeout(labels httpclient.MetricLabels) {
+	c.timeouts.WithLabelValues(labels.Client, labels.Method, labels.Route).Inc()
+}
+
+// IncHedge records a hedge
+func (c *Collector) IncHedge(labels httpclient.MetricLabels) {
+	c.hedges.WithLabelValues(labels.Client, labels.Method, labels.Route).Inc()
+}
+
+// SetCircuitState records state of a circuit
+func (c *Collector) SetCircuitState(client, commandKey string, state httpclient.CircuitState) {
+	c.circuitState.WithLabelValues(client, commandKey).Set(circuitStateValues[state])
+}
+
+// ObserveTiming records phases of an http attempt, phases not taking place are skipped
+func (c *Collector) ObserveTiming(labels httpclient.MetricLabels, timing httpclient.AttemptTiming) {
+	phases := map[string]time.Duration{
+		"dns":           timing.DNS,
+		"connect":       timing.Connect,
+		"tls_handshake": timing.TLSHandshake,
+		"ttfb":          timing.TimeToFirstByte,
+	}
+	for phase, duration := range phases {
+		if duration == 0 {
+			continue
+		}
+		c.phases.WithLabelValues(labels.Client, labels.Method, labels.Route, phase).Observe(duration.Seconds())
+	}
+	c.connections.WithLabelValues(labels.Client, labels.Method, labels.Route, strconv.FormatBool(timing.ConnReused)).Inc()
+}
@@ -0,0 +1,185 @@
+package promcollector
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	httpclient "playground/common/httpclient"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// this is just a helper
+func createTestServer(status int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+}
+
+// commandSeq makes command names unique across repeated test runs
+var commandSeq int64
+
+// this is just a helper, hystrix keeps circuits in globals,
+// so every test gets circuit of its own and flushes it afterwards
+func createCommandName(t *testing.T) string {
+	t.Cleanup(hystrix.Flush)
+	return fmt.Sprintf("%s-%d", t.Name(), atomic.AddInt64(&commandSeq, 1))
+}
+
+func Test_NewCollector_RegistersOnce(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	_, err := NewCollector(reg, Options{})
+	require.NoError(t, err)
+
+	_, err = NewCollector(reg, Options{})
+	assert.Error(t, err)
+}
+
+func Test_Collector_Requests(t *testing.T) {
+	server := createTestServer(http.StatusNoContent)
+	defer server.Close()
+
+	reg := prometheus.NewRegistry()
+	collector, err := NewCollector(reg, Options{Namespace: "test"})
+	require.NoError(t, err)
+
+	client := httpclient.NewHttpClient(httpclient.Config{
+		Name:             "zulu",
+		Host:             server.URL,
+		MetricsCollector: collector,
+	})
+
+	for i := 0; i < 2; i++ {
+		response, err := client.Get(httpclient.Parameter{
+			Path:       "/users/{id}",
+			PathParams: map[string]string{"id": "1"},
+		})
+		require.NoError(t, err)
+		response.Body.Close()
+	}
+
+	expected := `
+# HELP test_httpclient_requests_total http attempts which have reached the wire
+# TYPE test_httpclient_requests_total counter
+test_httpclient_requests_total{client="zulu",method="GET",route="/users/{id}",status_class="2xx"} 2
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "test_httpclient_requests_total"))
+	assert.Equal(t, 1, testutil.CollectAndCount(collector.latency))
+}
+
+func Test_Collector_RetriesAndCircuit(t *testing.T) {
+	server := createTestServer(http.StatusServiceUnavailable)
+	defer server.Close()
+
+	reg := prometheus.NewRegistry()
+	collector, err := NewCollector(reg, Options{})
+	require.NoError(t, err)
+
+	commandName := createCommandName(t)
+	client := httpclient.NewHttpClient(httpclient.Config{
+		Name:                  "zulu",
+		Host:                  server.URL,
+		RetryCount:            1,
+		RetryPolicy:           httpclient.ConstantRetryPolicy{Delay: time.Millisecond},
+		IsUsingCircuitBreaker: true,
+		CommandNamer:          httpclient.CommandNamerByName(commandName),
+		CbConfig: httpclient.CircuitBreakerConfig{
+			SleepWindow:            60000,
+			RequestVolumeThreshold: 2,
+		},
+		MetricsCollector: collector,
+	})
+
+	response, err := client.Get(httpclient.Parameter{Path: "/ping"})
+	require.Error(t, err)
+	require.NotNil(t, response)
+	response.Body.Close()
+
+	retries := collector.retries.WithLabelValues("zulu", http.MethodGet, "/ping")
+	assert.Equal(t, float64(1), testutil.ToFloat64(retries))
+	requests := collector.requests.WithLabelValues("zulu", http.MethodGet, "/ping", "5xx")
+	assert.Equal(t, float64(2), testutil.ToFloat64(requests))
+
+	inspector := client.(httpclient.CircuitInspector)
+	require.Eventually(t, func() bool {
+		return inspector.CircuitState()[commandName] == httpclient.CircuitOpen
+	}, time.Second, 10*time.Millisecond)
+
+	_, err = client.Get(httpclient.Parameter{Path: "/ping"})
+	require.ErrorIs(t, err, httpclient.ErrCircuitOpen)
+
+	shortCircuits := collector.shortCircuits.WithLabelValues("zulu", http.MethodGet, "/ping")
+	assert.GreaterOrEqual(t, testutil.ToFloat64(shortCircuits), float64(1))
+	assert.Equal(t, float64(2), testutil.ToFloat64(collector.circuitState.WithLabelValues("zulu", commandName)))
+}
+
+func Test_Collector_Timing(t *testing.T) {
+	server := createTestServer(http.StatusNoContent)
+	defer server.Close()
+
+	reg := prometheus.NewRegistry()
+	collector, err := NewCollector(reg, Options{Namespace: "test"})
+	require.NoError(t, err)
+
+	client := httpclient.NewHttpClient(httpclient.Config{
+		Name:             "zulu",
+		Host:             server.URL,
+		MetricsCollector: collector,
+	})
+
+	for i := 0; i < 2; i++ {
+		response, err := client.Get(httpclient.Parameter{Path: "/ping"})
+		require.NoError(t, err)
+		response.Body.Close()
+	}
+
+	expected := `
+# HELP test_httpclient_connections_total connections used by http attempts, by whether they are reused
+# TYPE test_httpclient_connections_total counter
+test_httpclient_connections_total{client="zulu",method="GET",reused="false",route="/ping"} 1
+test_httpclient_connections_total{client="zulu",method="GET",reused="true",route="/ping"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "test_httpclient_connections_total"))
+
+	// server is reached by ip, hence no dns nor tls
+	assert.Equal(t, 2, testutil.CollectAndCount(collector.phases))
+}
+
+func Test_Collector_Hedges(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	reg := prometheus.NewRegistry()
+	collector, err := NewCollector(reg, Options{Namespace: "test"})
+	require.NoError(t, err)
+
+	client := httpclient.NewHttpClient(httpclient.Config{
+		Name:             "zulu",
+		Host:             server.URL,
+		MetricsCollector: collector,
+		Hedging:          &httpclient.HedgingConfig{Delay: time.Millisecond, MaxRatio: 1},
+	})
+
+	response, err := client.Get(httpclient.Parameter{Path: "/ping"})
+	require.NoError(t, err)
+	response.Body.Close()
+
+	expected := `
+# HELP test_httpclient_hedges_total hedges fired for slow attempts
+# TYPE test_httpclient_hedges_total counter
+test_httpclient_hedges_total{client="zulu",method="GET",route="/ping"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "test_httpclient_hedges_total"))
+}
@@ -0,0 +1,307 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// RequestLogConfig enables logging of every attempt's request and response into Config.Logger.
+// successful attempts are logged at debug level, failing ones at warn level
+type RequestLogConfig struct {
+	MaxBodySize      int      // max bytes of request and response body logged, default 1024, negative to omit bodies
+	RedactHeaders    []string // headers whose values are redacted, default DefaultRedactedHeaders
+	RedactJSONFields []string // json body fields, at any depth, whose values are redacted, case-insensitive
+	SampleRate       float64  // fraction, 0-1, of successful attempts logged, default 1. failing attempts are always logged
+}
+
+// DefaultRedactedHeaders are headers redacted when RequestLogConfig.RedactHeaders is not defined
+var DefaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// redacted replaces sensitive values in logs
+const redacted = "[REDACTED]"
+
+// default values for RequestLogConfig
+const (
+	defaultRequestLogMaxBodySize = 1024
+	defaultRequestLogSampleRate  = 1
+)
+
+// requestLogger logs requests and responses based on RequestLogConfig
+type requestLogger struct {
+	config        RequestLogConfig
+	logger        Logger
+	classifier    ResponseClassifier
+	redactHeaders map[string]bool
+	redactFields  map[string]bool
+	fieldPattern  *regexp.Regexp // redacts scalar fields of bodies which can't be parsed, e.g. truncated
+}
+
+// newRequestLogger initiates requestLogger, applying RequestLogConfig defaults
+func newRequestLogger(config RequestLogConfig, logger Logger, classifier ResponseClassifier) *requestLogger {
+	// set default value if not defined
+	if config.MaxBodySize == 0 {
+		config.MaxBodySize = defaultRequestLogMaxBodySize
+	}
+
+	// set default value if not defined
+	if config.RedactHeaders == nil {
+		config.RedactHeaders = DefaultRedactedHeaders
+	}
+
+	// set default value if not defined
+	if config.SampleRate == 0 {
+		config.SampleRate = defaultRequestLogSampleRate
+	}
+
+	l := &requestLogger{
+		config:        config,
+		logger:        logger,
+		classifier:    classifier,
+		redactHeaders: map[string]bool{},
+		redactFields:  map[string]bool{},
+	}
+	for _, header := range config.RedactHeaders {
+		l.redactHeaders[http.CanonicalHeaderKey(header)] = true
+	}
+
+	fields := []string{}
+	for _, field := range config.RedactJSONFields {
+		l.redactFields[strings.ToLower(field)] = true
+		fields = append(fields, regexp.QuoteMeta(field))
+	}
+	if len(fields) > 0 {
+		l.fieldPattern = regexp.MustCompile(`(?i)("(?:` + strings.Join(fields, "|") + `)"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+	}
+	return l
+}
+
+// requestLogMiddleware logs every attempt as it goes on the wire.
+// bodies are only captured for attempts which are logged, response body is captured as the caller reads it,
+// hence its entry is logged once the body is closed
+func (hc *Client) requestLogMiddleware(next Doer) Doer {
+	l := newRequestLogger(*hc.config.RequestLog, hc.config.Logger, hc.config.ResponseClassifier)
+
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		// successful attempts are sampled upfront, failing ones are only known afterwards
+		isSampled := l.enabled(LevelDebug) && rand.Float64() < l.config.SampleRate
+		if !isSampled && !l.enabled(LevelWarn) {
+			return next.Do(req)
+		}
+
+		req, timer := withAttemptTimer(req)
+		res, err := next.Do(req)
+
+		level, msg := LevelDebug, "httpclient request"
+		switch {
+		case err != nil && isLostHedge(req.Context()):
+			// attempt cancelled because its hedge has won is not a failure
+			msg = "httpclient request lost to hedge"
+			if !l.enabled(LevelDebug) {
+				return res, err
+			}
+		case err != nil || (res != nil && l.classifier(res)):
+			level, msg = LevelWarn, "httpclient request failed"
+		case !isSampled:
+			return res, err
+		}
+
+		timing := timer.timing()
+		keyvals := []interface{}{
+			"method", req.Method,
+			"url", req.URL.String(),
+			"request_headers", l.headers(req.Header),
+		}
+		if l.config.MaxBodySize >= 0 {
+			keyvals = append(keyvals, "request_body", l.requestBody(req))
+		}
+		keyvals = append(keyvals, "duration", timing.Total)
+		keyvals = append(keyvals, timingKeyvals(timing)...)
+		if res != nil {
+			keyvals = append(keyvals,
+				"status", res.StatusCode,
+				"response_headers", l.headers(res.Header),
+			)
+		}
+		if err != nil {
+			keyvals = append(keyvals, "error", err)
+		}
+
+		if res == nil || l.config.MaxBodySize < 0 {
+			l.log(level, msg, keyvals)
+			return res, err
+		}
+		if res.Body == nil || res.Body == http.NoBody {
+			l.log(level, msg, append(keyvals, "response_body", ""))
+			return res, err
+		}
+
+		contentType := res.Header.Get("Content-Type")
+		res.Body = &loggedBody{
+			ReadCloser: res.Body,
+			mutex:      &sync.Mutex{},
+			limit:      l.config.MaxBodySize + 1,
+			emit: func(snippet []byte) {
+				l.log(level, msg, append(keyvals, "response_body", l.body(snippet, contentType)))
+			},
+		}
+		return res, err
+	})
+}
+
+// enabled is a helper to check whether the logger writes entries of a level,
+// loggers not implementing LevelEnabler are assumed to write every level
+func (l *requestLogger) enabled(level Level) bool {
+	enabler, ok := l.logger.(LevelEnabler)
+	return !ok || enabler.Enabled(level)
+}
+
+// log is a helper to write an entry at a level
+func (l *requestLogger) log(level Level, msg string, keyvals []interface{}) {
+	if level == LevelWarn {
+		l.logger.Warn(msg, keyvals...)
+		return
+	}
+	l.logger.Debug(msg, keyvals...)
+}
+
+// headers is a helper to copy headers with sensitive values redacted
+func (l *requestLogger) headers(header http.Header) http.Header {
+	copied := header.Clone()
+	for key := range copied {
+		if l.redactHeaders[http.CanonicalHeaderKey(key)] {
+			copied[key] = []string{redacted}
+		}
+	}
+	return copied
+}
+
+// requestBody is a helper to read request body without consuming it,
+// only replayable body, i.e. having GetBody, is read. multipart body is not,
+// its files would be reopened only to be logged
+func (l *requestLogger) requestBody(req *http.Request) string {
+	if req.Body == nil || req.Body == http.NoBody {
+		return ""
+	}
+	if mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); mediaType == ContentTypeMultipart {
+		return "[multipart]"
+	}
+	if req.GetBody == nil {
+		return "[not replayable]"
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return "[not replayable]"
+	}
+	defer body.Close()
+
+	snippet, _ := ioutil.ReadAll(io.LimitReader(body, int64(l.config.MaxBodySize)+1))
+	return l.body(snippet, req.Header.Get("Content-Type"))
+}
+
+// loggedBody is a response body capturing what the caller reads of it, up to limit,
+// emitting the log entry on close
+type loggedBody struct {
+	io.ReadCloser
+	mutex     *sync.Mutex
+	limit     int
+	captured  []byte
+	isEmitted bool
+	emit      func(snippet []byte)
+}
+
+// Read implements io.Reader
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if remaining := b.limit - len(b.captured); remaining > 0 {
+		if remaining > n {
+			remaining = n
+		}
+		b.captured = append(b.captured, p[:remaining]...)
+	}
+	return n, err
+}
+
+// Close implements io.Closer
+func (b *loggedBody) Close() error {
+	err := b.ReadCloser.Close()
+
+	b.mutex.Lock()
+	isEmitted := b.isEmitted
+	b.isEmitted = true
+	b.mutex.Unlock()
+
+	if !isEmitted {
+		b.emit(b.captured)
+	}
+	return err
+}
+
+// body is a helper to cap body and redact its json fields
+func (l *requestLogger) body(snippet []byte, contentType string) string {
+	isTruncated := len(snippet) > l.config.MaxBodySize
+	if isTruncated {
+		snippet = snippet[:l.config.MaxBodySize]
+	}
+
+	if len(l.redactFields) > 0 && isJSON(contentType) {
+		snippet = l.redactJSON(snippet, isTruncated)
+	}
+
+	if isTruncated {
+		return string(snippet) + "...[truncated]"
+	}
+	return string(snippet)
+}
+
+// redactJSON is a helper to redact sensitive fields of a json body.
+// complete body is redacted structurally, truncated one falls back to redacting scalar values
+func (l *requestLogger) redactJSON(body []byte, isTruncated bool) []byte {
+	if !isTruncated {
+		var value interface{}
+		if err := json.Unmarshal(body, &value); err == nil {
+			if redactedBody, err := json.Marshal(l.redactValue(value)); err == nil {
+				return redactedBody
+			}
+		}
+	}
+	return l.fieldPattern.ReplaceAll(body, []byte(`${1}"`+redacted+`"`))
+}
+
+// redactValue is a helper to walk a decoded json value, redacting sensitive fields
+func (l *requestLogger) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, inner := range v {
+			if l.redactFields[strings.ToLower(key)] {
+				v[key] = redacted
+				continue
+			}
+			v[key] = l.redactValue(inner)
+		}
+	case []interface{}:
+		for i, inner := range v {
+			v[i] = l.redactValue(inner)
+		}
+	}
+	return value
+}
+
+// isJSON is a helper to check whether content type is json, including +json suffixes
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == ContentTypeJSON || strings.HasSuffix(mediaType, "+json")
+}
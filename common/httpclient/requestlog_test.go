@@ -0,0 +1,206 @@
+package httpclient
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RequestLog_Redacts(t *testing.T) {
+	server := createConfiguredTestServer(testServerConfig{
+		header: http.Header{"Content-Type": {ContentTypeJSON}, "Set-Cookie": {"session=secret"}},
+		body:   `{"token":"response-secret","data":{"name":"zulu"}}`,
+	})
+	defer server.Close()
+
+	logger := createRecordingLogger()
+	client := NewHttpClient(Config{
+		Host:   server.URL,
+		Logger: logger,
+		RequestLog: &RequestLogConfig{
+			RedactJSONFields: []string{"password", "Token"},
+		},
+	})
+
+	response, err := client.Post(Parameter{
+		Path:   "/login",
+		Header: map[string]string{"Authorization": "Bearer secret", "X-Request-Id": "1"},
+		Body: map[string]interface{}{
+			"user":   map[string]string{"name": "alpha", "password": "request-secret"},
+			"scopes": []string{"read"},
+		},
+	})
+	require.NoError(t, err)
+
+	// body is still readable after being logged
+	body, err := ioutil.ReadAll(response.Body)
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, `{"token":"response-secret","data":{"name":"zulu"}}`, string(body))
+
+	entries := logger.find("httpclient request")
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, LevelDebug, entry.level)
+	assert.Equal(t, http.MethodPost, entry.keyvals["method"])
+	assert.Equal(t, http.StatusOK, entry.keyvals["status"])
+
+	requestHeaders := entry.keyvals["request_headers"].(http.Header)
+	assert.Equal(t, redacted, requestHeaders.Get("Authorization"))
+	assert.Equal(t, "1", requestHeaders.Get("X-Request-Id"))
+	responseHeaders := entry.keyvals["response_headers"].(http.Header)
+	assert.Equal(t, redacted, responseHeaders.Get("Set-Cookie"))
+
+	assert.JSONEq(t, `{"user":{"name":"alpha","password":"[REDACTED]"},"scopes":["read"]}`, entry.keyvals["request_body"].(string))
+	assert.JSONEq(t, `{"token":"[REDACTED]","data":{"name":"zulu"}}`, entry.keyvals["response_body"].(string))
+}
+
+func Test_RequestLog_TruncatesBody(t *testing.T) {
+	server := createConfiguredTestServer(testServerConfig{
+		header: http.Header{"Content-Type": {ContentTypeJSON}},
+		body:   `{"password":"response-secret","padding":"` + strings.Repeat("x", 64) + `"}`,
+	})
+	defer server.Close()
+
+	logger := createRecordingLogger()
+	client := NewHttpClient(Config{
+		Host:   server.URL,
+		Logger: logger,
+		RequestLog: &RequestLogConfig{
+			MaxBodySize:      32,
+			RedactJSONFields: []string{"password"},
+		},
+	})
+
+	response, err := client.Get(Parameter{Path: "/ping"})
+	require.NoError(t, err)
+	assert.Empty(t, logger.find("httpclient request"))
+
+	// entry is logged with as much of the body as the caller has read, once it is closed
+	_, err = ioutil.ReadAll(response.Body)
+	require.NoError(t, err)
+	response.Body.Close()
+
+	entries := logger.find("httpclient request")
+	require.Len(t, entries, 1)
+	responseBody := entries[0].keyvals["response_body"].(string)
+	assert.True(t, strings.HasSuffix(responseBody, "...[truncated]"), responseBody)
+	assert.NotContains(t, responseBody, "response-secret")
+	assert.Contains(t, responseBody, `"password":"[REDACTED]"`)
+	assert.Equal(t, "", entries[0].keyvals["request_body"])
+}
+
+func Test_RequestLog_Sampling(t *testing.T) {
+	server := createConfiguredTestServer(testServerConfig{
+		header: http.Header{"Content-Type": {ContentTypeJSON}},
+		body:   `{}`,
+	})
+	defer server.Close()
+	failing := createConfiguredTestServer(testServerConfig{
+		status: http.StatusInternalServerError,
+		header: http.Header{"Content-Type": {ContentTypeJSON}},
+		body:   `{}`,
+	})
+	defer failing.Close()
+
+	logger := createRecordingLogger()
+	config := Config{
+		Logger:     logger,
+		RequestLog: &RequestLogConfig{SampleRate: 1e-12, MaxBodySize: -1},
+	}
+
+	config.Host = server.URL
+	client := NewHttpClient(config)
+	for i := 0; i < 10; i++ {
+		response, err := client.Get(Parameter{Path: "/ping"})
+		require.NoError(t, err)
+		response.Body.Close()
+	}
+	assert.Empty(t, logger.find("httpclient request"))
+
+	// failing attempts are always logged
+	config.Host = failing.URL
+	client = NewHttpClient(config)
+	response, err := client.Get(Parameter{Path: "/ping"})
+	require.Error(t, err)
+	response.Body.Close()
+
+	entries := logger.find("httpclient request failed")
+	require.Len(t, entries, 1)
+	assert.Equal(t, LevelWarn, entries[0].level)
+	assert.NotContains(t, entries[0].keyvals, "response_body")
+	assert.Contains(t, entries[0].keyvals, "error")
+}
+
+func Test_RequestLog_StreamingResponse(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first,"))
+		w.(http.Flusher).Flush()
+		<-release
+		w.Write([]byte("second"))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	logger := createRecordingLogger()
+	client := NewHttpClient(Config{
+		Host:       server.URL,
+		Logger:     logger,
+		RequestLog: &RequestLogConfig{},
+	})
+
+	// response is returned as soon as its headers arrive, not once its body is complete
+	responses := make(chan *http.Response, 1)
+	go func() {
+		response, err := client.Get(Parameter{Path: "/stream"})
+		assert.NoError(t, err)
+		responses <- response
+	}()
+
+	var response *http.Response
+	select {
+	case response = <-responses:
+	case <-time.After(time.Second):
+		t.Fatal("request log blocks on streaming response")
+	}
+
+	first := make([]byte, len("first,"))
+	_, err := io.ReadFull(response.Body, first)
+	require.NoError(t, err)
+	response.Body.Close()
+
+	entries := logger.find("httpclient request")
+	require.Len(t, entries, 1)
+	assert.Equal(t, "first,", entries[0].keyvals["response_body"])
+}
+
+func Test_RequestLog_DisabledLevel(t *testing.T) {
+	var hits int32
+	server := createConfiguredTestServer(testServerConfig{status: http.StatusInternalServerError, hits: &hits})
+	defer server.Close()
+
+	out := &bytes.Buffer{}
+	client := NewHttpClient(Config{
+		Host:       server.URL,
+		Logger:     NewStdLogger(out, LevelError),
+		RequestLog: &RequestLogConfig{},
+	})
+
+	response, err := client.Post(Parameter{Path: "/ping", Body: map[string]string{"someKey": "someValue"}})
+	require.Error(t, err)
+	response.Body.Close()
+
+	// nothing is captured for entries which would be discarded
+	_, isLogged := response.Body.(*loggedBody)
+	assert.False(t, isLogged)
+	assert.Empty(t, out.String())
+}
@@ -0,0 +1,114 @@
+package httpclient
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy decides the wait time between retries
+type RetryPolicy interface {
+	// Backoff returns the wait time before the n-th retry (starting from 1).
+	// previous is the wait time before the last retry, elapsed is the time spent since the first attempt.
+	// returning false stops the retry mechanism
+	Backoff(retry int, previous, elapsed time.Duration) (time.Duration, bool)
+}
+
+// RetryLimit caps a RetryPolicy, zero value means no cap.
+// it is meant to be embedded into RetryPolicy implementations
+type RetryLimit struct {
+	MaxDelay       time.Duration // maximum wait time of a single retry
+	MaxElapsedTime time.Duration // maximum total time spent since the first attempt, retry that would exceed it is not executed
+}
+
+// limit is a helper to apply the caps to a computed wait time
+func (l RetryLimit) limit(delay, elapsed time.Duration) (time.Duration, bool) {
+	if l.MaxDelay > 0 && delay > l.MaxDelay {
+		delay = l.MaxDelay
+	}
+	if l.MaxElapsedTime > 0 && elapsed+delay > l.MaxElapsedTime {
+		return 0, false
+	}
+	return delay, true
+}
+
+// ConstantRetryPolicy waits the same amount of time before every retry
+type ConstantRetryPolicy struct {
+	RetryLimit
+	Delay time.Duration
+}
+
+// Backoff implements RetryPolicy
+func (p ConstantRetryPolicy) Backoff(retry int, previous, elapsed time.Duration) (time.Duration, bool) {
+	return p.limit(p.Delay, elapsed)
+}
+
+// LinearRetryPolicy waits Step * n before the n-th retry
+type LinearRetryPolicy struct {
+	RetryLimit
+	Step time.Duration
+}
+
+// Backoff implements RetryPolicy
+func (p LinearRetryPolicy) Backoff(retry int, previous, elapsed time.Duration) (time.Duration, bool) {
+	return p.limit(time.Duration(retry)*p.Step, elapsed)
+}
+
+// ExponentialRetryPolicy waits Initial * Multiplier^(n-1) before the n-th retry
+type ExponentialRetryPolicy struct {
+	RetryLimit
+	Initial    time.Duration
+	Multiplier float64 // default 2
+}
+
+// Backoff implements RetryPolicy
+func (p ExponentialRetryPolicy) Backoff(retry int, previous, elapsed time.Duration) (time.Duration, bool) {
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	// avoid overflowing on large retry count
+	delay := time.Duration(math.MaxInt64)
+	if d := float64(p.Initial) * math.Pow(multiplier, float64(retry-1)); d < math.MaxInt64 {
+		delay = time.Duration(d)
+	}
+
+	return p.limit(delay, elapsed)
+}
+
+// DecorrelatedJitterRetryPolicy waits a random time between Base and 3 times the previous wait time,
+// spreading retries of concurrent callers apart
+type DecorrelatedJitterRetryPolicy struct {
+	RetryLimit
+	Base time.Duration
+}
+
+// Backoff implements RetryPolicy
+func (p DecorrelatedJitterRetryPolicy) Backoff(retry int, previous, elapsed time.Duration) (time.Duration, bool) {
+	if previous < p.Base {
+		previous = p.Base
+	}
+
+	delay := p.Base
+	if upper := 3 * previous; upper > p.Base {
+		delay = p.Base + time.Duration(rand.Int63n(int64(upper-p.Base)))
+	}
+
+	return p.limit(delay, elapsed)
+}
+
+// sleepContext is a helper to wait for the given duration,
+// returning early with ctx.Err() when the context is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,150 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ConstantRetryPolicy(t *testing.T) {
+	policy := ConstantRetryPolicy{Delay: 100 * time.Millisecond}
+
+	for retry := 1; retry <= 3; retry++ {
+		wait, ok := policy.Backoff(retry, 0, 0)
+		require.True(t, ok)
+		assert.Equal(t, 100*time.Millisecond, wait)
+	}
+}
+
+func Test_LinearRetryPolicy(t *testing.T) {
+	policy := LinearRetryPolicy{Step: time.Second}
+
+	wait, _ := policy.Backoff(1, 0, 0)
+	assert.Equal(t, time.Second, wait)
+	wait, _ = policy.Backoff(3, 0, 0)
+	assert.Equal(t, 3*time.Second, wait)
+}
+
+func Test_ExponentialRetryPolicy(t *testing.T) {
+	policy := ExponentialRetryPolicy{Initial: 100 * time.Millisecond}
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond}
+	for i, e := range expected {
+		wait, ok := policy.Backoff(i+1, 0, 0)
+		require.True(t, ok)
+		assert.Equal(t, e, wait)
+	}
+}
+
+func Test_ExponentialRetryPolicy_MaxDelay(t *testing.T) {
+	policy := ExponentialRetryPolicy{
+		RetryLimit: RetryLimit{MaxDelay: time.Second},
+		Initial:    100 * time.Millisecond,
+		Multiplier: 10,
+	}
+
+	wait, ok := policy.Backoff(5, 0, 0)
+	require.True(t, ok)
+	assert.Equal(t, time.Second, wait)
+
+	wait, ok = policy.Backoff(1000, 0, 0)
+	require.True(t, ok)
+	assert.Equal(t, time.Second, wait)
+}
+
+func Test_RetryLimit_MaxElapsedTime(t *testing.T) {
+	policy := ConstantRetryPolicy{
+		RetryLimit: RetryLimit{MaxElapsedTime: time.Second},
+		Delay:      300 * time.Millisecond,
+	}
+
+	_, ok := policy.Backoff(1, 0, 700*time.Millisecond)
+	assert.True(t, ok)
+
+	_, ok = policy.Backoff(2, 0, 800*time.Millisecond)
+	assert.False(t, ok)
+}
+
+func Test_DecorrelatedJitterRetryPolicy(t *testing.T) {
+	policy := DecorrelatedJitterRetryPolicy{
+		RetryLimit: RetryLimit{MaxDelay: time.Second},
+		Base:       10 * time.Millisecond,
+	}
+
+	var previous time.Duration
+	for retry := 1; retry <= 50; retry++ {
+		wait, ok := policy.Backoff(retry, previous, 0)
+		require.True(t, ok)
+		assert.GreaterOrEqual(t, int64(wait), int64(policy.Base))
+		assert.LessOrEqual(t, int64(wait), int64(time.Second))
+		if previous > 0 {
+			assert.LessOrEqual(t, int64(wait), int64(3*previous))
+		}
+		previous = wait
+	}
+}
+
+func Test_DoVanilla_RetriesWithPolicy(t *testing.T) {
+	var hits int32
+	server := createConfiguredTestServer(testServerConfig{status: http.StatusServiceUnavailable, hits: &hits})
+	defer server.Close()
+
+	client := NewHttpClient(Config{
+		Host:        server.URL,
+		RetryCount:  3,
+		RetryPolicy: ConstantRetryPolicy{Delay: 10 * time.Millisecond},
+	})
+
+	_, err := client.Get(Parameter{Path: "/ping"})
+	require.Error(t, err)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&hits))
+}
+
+func Test_DoVanilla_RetryStopsOnMaxElapsedTime(t *testing.T) {
+	var hits int32
+	server := createConfiguredTestServer(testServerConfig{status: http.StatusServiceUnavailable, hits: &hits})
+	defer server.Close()
+
+	client := NewHttpClient(Config{
+		Host:       server.URL,
+		RetryCount: 10,
+		RetryPolicy: ConstantRetryPolicy{
+			RetryLimit: RetryLimit{MaxElapsedTime: 50 * time.Millisecond},
+			Delay:      20 * time.Millisecond,
+		},
+	})
+
+	response, err := client.Get(Parameter{Path: "/ping"})
+	require.Error(t, err)
+
+	// last failing response is still returned
+	require.NotNil(t, response)
+	response.Body.Close()
+	assert.Less(t, atomic.LoadInt32(&hits), int32(4))
+}
+
+func Test_DoVanilla_RetryStopsOnContextCancelled(t *testing.T) {
+	var hits int32
+	server := createConfiguredTestServer(testServerConfig{status: http.StatusServiceUnavailable, hits: &hits})
+	defer server.Close()
+
+	client := NewHttpClient(Config{
+		Host:        server.URL,
+		RetryCount:  1,
+		RetryPolicy: ConstantRetryPolicy{Delay: time.Minute},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.GetWithContext(ctx, Parameter{Path: "/ping"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
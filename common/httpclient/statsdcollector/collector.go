@@ -0,0 +1,230 @@
+// Package statsdcollector ships hystrix metrics of httpclient's circuits to StatsD or DogStatsD,
+// following hystrix-go's plugins metric names. plug it into httpclient.Config.CircuitCollector, e.g.
+//
+//	collector, err := statsdcollector.NewDogStatsd(statsdcollector.Config{
+//		Addr:      "localhost:8125",
+//		Namespace: "alpha",
+//	})
+//	if err != nil {
+//		panic(err)
+//	}
+//	defer collector.Close()
+//
+//	client := httpclient.NewHttpClient(httpclient.Config{
+//		Name:             "zulu",
+//		Host:             "http://localhost:3002",
+//		CircuitCollector: collector.CircuitCollector(),
+//		...
+//	})
+package statsdcollector
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	httpclient "playground/common/httpclient"
+
+	"github.com/DataDog/datadog-go/statsd"
+	metricCollector "github.com/afex/hystrix-go/hystrix/metric_collector"
+	"github.com/afex/hystrix-go/plugins"
+	cactus "github.com/cactus/go-statsd-client/statsd"
+)
+
+// Config is the collectors configuration
+type Config struct {
+	Addr          string        // host:port of StatsD or DogStatsD agent, e.g. "localhost:8125"
+	Namespace     string        // prefix of every metric name
+	Tags          []string      // constant tags on every metric, e.g. "env:production", DogStatsD only
+	FlushInterval time.Duration // how often buffered metrics are sent, default 100ms
+	FlushBytes    int           // max packet size, StatsD only, default plugins.LANStatsdFlushBytes
+	SampleRate    float32       // StatsD only, default 1, no sampling
+}
+
+// default values for Config
+const (
+	defaultFlushInterval = 100 * time.Millisecond
+	defaultSampleRate    = 1
+)
+
+// Statsd ships circuit metrics to plain StatsD, named as hystrix-go's statsd plugin.
+// StatsD has no tags, so client name is put into metric name instead:
+// {namespace}.{client}.{command key}.{metric}, e.g. "alpha.zulu.http---localhost-3002.attempts"
+type Statsd struct {
+	statter    cactus.Statter
+	sampleRate float32
+}
+
+// NewStatsd initiates StatsD collector
+func NewStatsd(config Config) (*Statsd, error) {
+	// set default value if not defined
+	if config.FlushInterval == 0 {
+		config.FlushInterval = defaultFlushInterval
+	}
+
+	// set default value if not defined
+	if config.FlushBytes == 0 {
+		config.FlushBytes = plugins.LANStatsdFlushBytes
+	}
+
+	// set default value if not defined
+	if config.SampleRate == 0 {
+		config.SampleRate = defaultSampleRate
+	}
+
+	statter, err := cactus.NewClientWithConfig(&cactus.ClientConfig{
+		Address:       config.Addr,
+		Prefix:        strings.TrimSuffix(config.Namespace, "."),
+		UseBuffered:   true,
+		FlushInterval: config.FlushInterval,
+		FlushBytes:    config.FlushBytes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Statsd{statter: statter, sampleRate: config.SampleRate}, nil
+}
+
+// CircuitCollector returns the factory to be set on httpclient.Config.CircuitCollector
+func (s *Statsd) CircuitCollector() httpclient.CircuitCollectorFactory {
+	return func(circuit httpclient.CircuitInfo) metricCollector.MetricCollector {
+		prefix := sanitize(circuit.CommandKey)
+		if circuit.Client != "" {
+			prefix = sanitize(circuit.Client) + "." + prefix
+		}
+		return &statsdCircuit{
+			statter:    s.statter.NewSubStatter(prefix),
+			sampleRate: s.sampleRate,
+		}
+	}
+}
+
+// Close flushes and closes StatsD client
+func (s *Statsd) Close() error {
+	return s.statter.Close()
+}
+
+// statsdCircuit is hystrix metric collector of a circuit sending to StatsD
+type statsdCircuit struct {
+	statter    cactus.SubStatter
+	sampleRate float32
+}
+
+// Update sends result of a command execution,
+// sending errors are ignored, metrics are best effort
+func (c *statsdCircuit) Update(r metricCollector.MetricResult) {
+	if r.Successes > 0 {
+		c.statter.Gauge("circuitOpen", 0, c.sampleRate)
+	} else if r.ShortCircuits > 0 {
+		c.statter.Gauge("circuitOpen", 1, c.sampleRate)
+	}
+
+	counters := []struct {
+		name  string
+		value float64
+	}{
+		{"attempts", r.Attempts},
+		{"errors", r.Errors},
+		{"successes", r.Successes},
+		{"failures", r.Failures},
+		{"rejects", r.Rejects},
+		{"shortCircuits", r.ShortCircuits},
+		{"timeouts", r.Timeouts},
+		{"fallbackSuccesses", r.FallbackSuccesses},
+		{"fallbackFailures", r.FallbackFailures},
+		{"contextCanceled", r.ContextCanceled},
+		{"contextDeadlineExceeded", r.ContextDeadlineExceeded},
+	}
+	for _, counter := range counters {
+		if counter.value > 0 {
+			c.statter.Inc(counter.name, int64(counter.value), c.sampleRate)
+		}
+	}
+
+	c.statter.TimingDuration("totalDuration", r.TotalDuration, c.sampleRate)
+	c.statter.TimingDuration("runDuration", r.RunDuration, c.sampleRate)
+}
+
+// Reset is a noop, StatsD aggregates on its own
+func (c *statsdCircuit) Reset() {}
+
+// DogStatsd ships circuit metrics to DogStatsD, named as hystrix-go's datadog plugin, e.g. "alpha.hystrix.attempts",
+// tagged with "hystrixcircuit:{command key}", "client:{client name}" and, for circuits per route, "route:{route template}"
+type DogStatsd struct {
+	client *statsd.Client
+}
+
+// NewDogStatsd initiates DogStatsD collector
+func NewDogStatsd(config Config) (*DogStatsd, error) {
+	// set default value if not defined
+	if config.FlushInterval == 0 {
+		config.FlushInterval = defaultFlushInterval
+	}
+
+	options := []statsd.Option{
+		statsd.WithTags(config.Tags),
+		statsd.WithBufferFlushInterval(config.FlushInterval),
+	}
+	if config.Namespace != "" {
+		options = append(options, statsd.WithNamespace(strings.TrimSuffix(config.Namespace, ".")+"."))
+	}
+
+	client, err := statsd.New(config.Addr, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &DogStatsd{client: client}, nil
+}
+
+// CircuitCollector returns the factory to be set on httpclient.Config.CircuitCollector
+func (d *DogStatsd) CircuitCollector() httpclient.CircuitCollectorFactory {
+	return func(circuit httpclient.CircuitInfo) metricCollector.MetricCollector {
+		tags := []string{"client:" + circuit.Client}
+		if circuit.Route != "" {
+			tags = append(tags, "route:"+circuit.Route)
+		}
+
+		newCollector := plugins.NewDatadogCollectorWithClient(&taggedClient{DatadogClient: d.client, tags: tags})
+		return newCollector(circuit.CommandKey)
+	}
+}
+
+// Close flushes and closes DogStatsD client
+func (d *DogStatsd) Close() error {
+	return d.client.Close()
+}
+
+// taggedClient adds circuit's tags to every metric sent through plugins.DatadogClient
+type taggedClient struct {
+	plugins.DatadogClient
+	tags []string
+}
+
+func (c *taggedClient) Count(name string, value int64, tags []string, rate float64) error {
+	return c.DatadogClient.Count(name, value, c.with(tags), rate)
+}
+
+func (c *taggedClient) Gauge(name string, value float64, tags []string, rate float64) error {
+	return c.DatadogClient.Gauge(name, value, c.with(tags), rate)
+}
+
+func (c *taggedClient) TimeInMilliseconds(name string, value float64, tags []string, rate float64) error {
+	return c.DatadogClient.TimeInMilliseconds(name, value, c.with(tags), rate)
+}
+
+// with is a helper to merge circuit's tags into metric's tags
+func (c *taggedClient) with(tags []string) []string {
+	merged := make([]string, 0, len(tags)+len(c.tags))
+	merged = append(merged, tags...)
+	return append(merged, c.tags...)
+}
+
+// invalidNameChars matches characters not allowed in a StatsD name segment,
+// "." is included as it separates segments
+var invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// sanitize is a helper to make a value safe as a single StatsD name segment,
+// e.g. "GET http://localhost:3002/users/{id}" into "GET-http---localhost-3002-users--id-"
+func sanitize(name string) string {
+	return invalidNameChars.ReplaceAllString(name, "-")
+}
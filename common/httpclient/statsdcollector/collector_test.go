@@ -0,0 +1,169 @@
+package statsdcollector
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	httpclient "playground/common/httpclient"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// commandSeq makes command names unique across repeated test runs
+var commandSeq int64
+
+// this is just a helper, hystrix keeps circuits in globals,
+// so every test gets circuit of its own and flushes it afterwards
+func createCommandName(t *testing.T) string {
+	t.Cleanup(hystrix.Flush)
+	return fmt.Sprintf("%s-%d", t.Name(), atomic.AddInt64(&commandSeq, 1))
+}
+
+// udpRecorder is a local stand-in of StatsD agent, recording every received metric line
+type udpRecorder struct {
+	conn  net.PacketConn
+	mutex *sync.Mutex
+	lines []string
+}
+
+// this is just a helper
+func createUDPRecorder(t *testing.T) *udpRecorder {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	recorder := &udpRecorder{conn: conn, mutex: &sync.Mutex{}}
+	go func() {
+		buf := make([]byte, 65536)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			recorder.mutex.Lock()
+			recorder.lines = append(recorder.lines, strings.Split(strings.TrimSpace(string(buf[:n])), "\n")...)
+			recorder.mutex.Unlock()
+		}
+	}()
+	return recorder
+}
+
+// find returns the first recorded line having the prefix
+func (r *udpRecorder) find(prefix string) (string, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, line := range r.lines {
+		if strings.HasPrefix(line, prefix) {
+			return line, true
+		}
+	}
+	return "", false
+}
+
+// this is just a helper
+func createTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func Test_Statsd(t *testing.T) {
+	recorder := createUDPRecorder(t)
+	server := createTestServer()
+	defer server.Close()
+
+	commandName := createCommandName(t)
+	collector, err := NewStatsd(Config{
+		Addr:          recorder.conn.LocalAddr().String(),
+		Namespace:     "alpha",
+		FlushInterval: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer collector.Close()
+
+	client := httpclient.NewHttpClient(httpclient.Config{
+		Name:                  "zulu",
+		Host:                  server.URL,
+		IsUsingCircuitBreaker: true,
+		CommandNamer:          httpclient.CommandNamerByName(commandName),
+		CircuitCollector:      collector.CircuitCollector(),
+	})
+
+	response, err := client.Get(httpclient.Parameter{Path: "/ping"})
+	require.NoError(t, err)
+	response.Body.Close()
+
+	require.Eventually(t, func() bool {
+		_, ok := recorder.find("alpha.zulu." + commandName + ".attempts:")
+		return ok
+	}, time.Second, 10*time.Millisecond)
+
+	line, _ := recorder.find("alpha.zulu." + commandName + ".attempts:")
+	assert.Equal(t, "alpha.zulu."+commandName+".attempts:1|c", line)
+	line, _ = recorder.find("alpha.zulu." + commandName + ".circuitOpen:")
+	assert.Equal(t, "alpha.zulu."+commandName+".circuitOpen:0|g", line)
+	_, ok := recorder.find("alpha.zulu." + commandName + ".runDuration:")
+	assert.True(t, ok)
+}
+
+func Test_DogStatsd(t *testing.T) {
+	recorder := createUDPRecorder(t)
+	server := createTestServer()
+	defer server.Close()
+
+	collector, err := NewDogStatsd(Config{
+		Addr:          recorder.conn.LocalAddr().String(),
+		Namespace:     "alpha",
+		Tags:          []string{"env:test"},
+		FlushInterval: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer collector.Close()
+
+	client := httpclient.NewHttpClient(httpclient.Config{
+		Name:                  "zulu",
+		Host:                  server.URL,
+		IsUsingCircuitBreaker: true,
+		CommandNamer:          httpclient.CommandNamerByRoute(),
+		CircuitCollector:      collector.CircuitCollector(),
+	})
+
+	response, err := client.Get(httpclient.Parameter{
+		Path:       "/users/{id}/dogstatsd",
+		PathParams: map[string]string{"id": "1"},
+	})
+	require.NoError(t, err)
+	response.Body.Close()
+
+	require.Eventually(t, func() bool {
+		_, ok := recorder.find("alpha.hystrix.attempts:")
+		return ok
+	}, time.Second, 10*time.Millisecond)
+
+	line, _ := recorder.find("alpha.hystrix.attempts:")
+	assert.True(t, strings.HasPrefix(line, "alpha.hystrix.attempts:1|c|#"), line)
+	for _, tag := range []string{
+		"env:test",
+		"hystrixcircuit:GET " + server.URL + "/users/{id}/dogstatsd",
+		"client:zulu",
+		"route:/users/{id}/dogstatsd",
+	} {
+		assert.Contains(t, line, tag)
+	}
+}
+
+func Test_Sanitize(t *testing.T) {
+	assert.Equal(t, "GET-http---localhost-3002-users--id-", sanitize("GET http://localhost:3002/users/{id}"))
+	assert.Equal(t, "some_name-1", sanitize("some_name-1"))
+}
@@ -0,0 +1,141 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// AttemptTiming breaks down where the time of an http attempt goes.
+// phases not taking place, e.g. dns and connect on a reused connection, are zero
+type AttemptTiming struct {
+	DNS             time.Duration // dns lookup
+	Connect         time.Duration // tcp connect
+	TLSHandshake    time.Duration // tls handshake
+	TimeToFirstByte time.Duration // from the attempt's start until the first response byte, including the phases above
+	Total           time.Duration // from the attempt's start until response headers are received, excluding body read
+	ConnReused      bool          // whether a pooled connection is reused
+}
+
+// TimingObserver is optionally implemented by MetricsCollector to receive AttemptTiming of every attempt
+// which has reached the wire, after ObserveRequest
+type TimingObserver interface {
+	ObserveTiming(labels MetricLabels, timing AttemptTiming)
+}
+
+// Timing returns AttemptTiming of the attempt which has produced res,
+// false when res doesn't come from the wire, e.g. fallback response
+func Timing(res *http.Response) (AttemptTiming, bool) {
+	if res == nil || res.Request == nil {
+		return AttemptTiming{}, false
+	}
+
+	timer, ok := res.Request.Context().Value(attemptTimerKey{}).(*attemptTimer)
+	if !ok {
+		return AttemptTiming{}, false
+	}
+	return timer.timing(), true
+}
+
+// attemptTimerKey is the context key of attemptTimer
+type attemptTimerKey struct{}
+
+// attemptTimer collects AttemptTiming out of httptrace hooks,
+// hooks may fire from dialing goroutines, hence the mutex
+type attemptTimer struct {
+	mutex        *sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	result       AttemptTiming
+}
+
+// withAttemptTimer is a helper to trace req with attemptTimer.
+// timer already on req's context, e.g. put by request logging, is reused so both see the same timing
+func withAttemptTimer(req *http.Request) (*http.Request, *attemptTimer) {
+	if timer, ok := req.Context().Value(attemptTimerKey{}).(*attemptTimer); ok {
+		return req, timer
+	}
+
+	timer := &attemptTimer{mutex: &sync.Mutex{}}
+	ctx := context.WithValue(req.Context(), attemptTimerKey{}, timer)
+	ctx = httptrace.WithClientTrace(ctx, timer.clientTrace())
+	return req.WithContext(ctx), timer
+}
+
+// begin marks the attempt being sent
+func (t *attemptTimer) begin() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.start = time.Now()
+	t.result = AttemptTiming{}
+}
+
+// end marks response headers being received, or the attempt failing
+func (t *attemptTimer) end() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.result.Total = time.Since(t.start)
+}
+
+// timing returns a copy of collected AttemptTiming
+func (t *attemptTimer) timing() AttemptTiming {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.result
+}
+
+// record is a helper to update collected AttemptTiming under lock
+func (t *attemptTimer) record(fn func()) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	fn()
+}
+
+// clientTrace is a helper to build httptrace hooks feeding the timer
+func (t *attemptTimer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.record(func() { t.dnsStart = time.Now() })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.record(func() { t.result.DNS = time.Since(t.dnsStart) })
+		},
+		ConnectStart: func(network, addr string) {
+			t.record(func() { t.connectStart = time.Now() })
+		},
+		ConnectDone: func(network, addr string, err error) {
+			if err != nil {
+				return
+			}
+			t.record(func() { t.result.Connect = time.Since(t.connectStart) })
+		},
+		TLSHandshakeStart: func() {
+			t.record(func() { t.tlsStart = time.Now() })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.record(func() { t.result.TLSHandshake = time.Since(t.tlsStart) })
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.record(func() { t.result.ConnReused = info.Reused })
+		},
+		GotFirstResponseByte: func() {
+			t.record(func() { t.result.TimeToFirstByte = time.Since(t.start) })
+		},
+	}
+}
+
+// timingKeyvals is a helper to flatten AttemptTiming into logger's keyvals
+func timingKeyvals(timing AttemptTiming) []interface{} {
+	return []interface{}{
+		"dns", timing.DNS,
+		"connect", timing.Connect,
+		"tls_handshake", timing.TLSHandshake,
+		"ttfb", timing.TimeToFirstByte,
+		"conn_reused", timing.ConnReused,
+	}
+}
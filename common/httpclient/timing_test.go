@@ -0,0 +1,99 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Timing(t *testing.T) {
+	server := createConfiguredTestServer(testServerConfig{status: http.StatusNoContent, delay: 50 * time.Millisecond})
+	defer server.Close()
+
+	client := NewHttpClient(Config{Host: server.URL})
+
+	for i, isReused := range []bool{false, true} {
+		response, err := client.Get(Parameter{Path: "/ping"})
+		require.NoError(t, err)
+		response.Body.Close()
+
+		timing, ok := Timing(response)
+		require.True(t, ok)
+		assert.Equal(t, isReused, timing.ConnReused, "attempt %d", i)
+		assert.GreaterOrEqual(t, timing.TimeToFirstByte, 50*time.Millisecond)
+		assert.GreaterOrEqual(t, timing.Total, timing.TimeToFirstByte)
+		if isReused {
+			assert.Zero(t, timing.Connect)
+		} else {
+			assert.NotZero(t, timing.Connect)
+		}
+	}
+}
+
+func Test_Timing_FallbackResponse(t *testing.T) {
+	var hits int32
+	server := createConfiguredTestServer(testServerConfig{status: http.StatusInternalServerError, hits: &hits})
+	defer server.Close()
+
+	client := NewHttpClient(Config{
+		Host:                  server.URL,
+		IsUsingCircuitBreaker: true,
+		CommandNamer:          CommandNamerByName(createCommandName(t)),
+		CbConfig: CircuitBreakerConfig{
+			ResponseFallback: StaticFallback(http.StatusOK, nil, []byte("{}")),
+		},
+	})
+
+	response, err := client.GetWithContext(context.Background(), Parameter{Path: "/ping"})
+	require.NoError(t, err)
+	response.Body.Close()
+	require.True(t, IsFallbackResponse(response))
+
+	_, ok := Timing(response)
+	assert.False(t, ok)
+	_, ok = Timing(nil)
+	assert.False(t, ok)
+}
+
+// timingRecorder is a MetricsCollector recording AttemptTiming
+type timingRecorder struct {
+	nopMetricsCollector
+	timings []AttemptTiming
+}
+
+func (r *timingRecorder) ObserveTiming(labels MetricLabels, timing AttemptTiming) {
+	r.timings = append(r.timings, timing)
+}
+
+func Test_Timing_ObservedAndLogged(t *testing.T) {
+	server := createConfiguredTestServer(testServerConfig{status: http.StatusNoContent, delay: 10 * time.Millisecond})
+	defer server.Close()
+
+	recorder := &timingRecorder{}
+	logger := createRecordingLogger()
+	client := NewHttpClient(Config{
+		Host:             server.URL,
+		MetricsCollector: recorder,
+		Logger:           logger,
+		RequestLog:       &RequestLogConfig{},
+	})
+
+	response, err := client.Get(Parameter{Path: "/ping"})
+	require.NoError(t, err)
+	response.Body.Close()
+
+	timing, ok := Timing(response)
+	require.True(t, ok)
+	require.Len(t, recorder.timings, 1)
+	assert.Equal(t, timing, recorder.timings[0])
+
+	entries := logger.find("httpclient request")
+	require.Len(t, entries, 1)
+	assert.Equal(t, timing.TimeToFirstByte, entries[0].keyvals["ttfb"])
+	assert.Equal(t, timing.Total, entries[0].keyvals["duration"])
+	assert.Equal(t, false, entries[0].keyvals["conn_reused"])
+}
@@ -0,0 +1,43 @@
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// GinMiddleware continues inbound traces, opening a server span per request
+// as a child of the caller's span carried in traceparent header.
+// the span is available to handlers through c.Request.Context()
+func (t *Tracer) GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := t.propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		ctx, span := t.tracer.Start(ctx, spanName(c.Request.Method, route),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethodKey.String(c.Request.Method),
+				semconv.HTTPTargetKey.String(c.Request.URL.RequestURI()),
+				semconv.HTTPRouteKey.String(route),
+			),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(status))
+		for _, err := range c.Errors {
+			span.RecordError(err.Err)
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	httpclient "playground/common/httpclient"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// callStateKey is the context key to share callState from call to attempt spans
+type callStateKey struct{}
+
+// callState collects what attempts of a call have seen
+type callState struct {
+	mutex      *sync.Mutex
+	attempts   int
+	circuitKey string
+}
+
+// attempt is a helper to register an attempt, returning its number
+func (s *callState) attempt(circuitKey string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.attempts++
+	if circuitKey != "" {
+		s.circuitKey = circuitKey
+	}
+	return s.attempts
+}
+
+// snapshot is a helper to read the collected state
+func (s *callState) snapshot() (int, string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.attempts, s.circuitKey
+}
+
+// CallMiddleware opens a span per logical call, covering retries, circuit breaker and fallback,
+// put it into httpclient.Config.CallMiddlewares
+func (t *Tracer) CallMiddleware(next httpclient.Doer) httpclient.Doer {
+	return httpclient.DoerFunc(func(req *http.Request) (*http.Response, error) {
+		route, _ := httpclient.RouteTemplate(req.Context())
+		ctx, span := t.tracer.Start(req.Context(), spanName(req.Method, route),
+			trace.WithSpanKind(trace.SpanKindInternal),
+			trace.WithAttributes(requestAttributes(req, route)...),
+		)
+		defer span.End()
+
+		state := &callState{mutex: &sync.Mutex{}}
+		ctx = context.WithValue(ctx, callStateKey{}, state)
+
+		res, err := next.Do(req.WithContext(ctx))
+
+		attempts, circuitKey := state.snapshot()
+		var circuitErr *httpclient.CircuitError
+		if circuitKey == "" && errors.As(err, &circuitErr) {
+			circuitKey = circuitErr.CommandKey
+		}
+		if circuitKey != "" {
+			span.SetAttributes(attribute.String(AttributeCircuitKey, circuitKey))
+		}
+		if attempts > 1 {
+			span.SetAttributes(attribute.Int(AttributeRetryCount, attempts-1))
+		}
+
+		var fallbackErr *httpclient.FallbackError
+		isFallback := (res != nil && httpclient.IsFallbackResponse(res)) || errors.As(err, &fallbackErr)
+		span.SetAttributes(attribute.Bool(AttributeFallback, isFallback))
+
+		endSpan(span, res, err)
+		return res, err
+	})
+}
+
+// AttemptMiddleware opens a child span per attempt and propagates its context to downstream,
+// put it into httpclient.Config.AttemptMiddlewares
+func (t *Tracer) AttemptMiddleware(next httpclient.Doer) httpclient.Doer {
+	return httpclient.DoerFunc(func(req *http.Request) (*http.Response, error) {
+		circuitKey, _ := httpclient.CommandKey(req.Context())
+		route, _ := httpclient.RouteTemplate(req.Context())
+
+		attributes := requestAttributes(req, route)
+		if state, ok := req.Context().Value(callStateKey{}).(*callState); ok {
+			attributes = append(attributes, attribute.Int(AttributeAttempt, state.attempt(circuitKey)))
+		}
+		if circuitKey != "" {
+			attributes = append(attributes, attribute.String(AttributeCircuitKey, circuitKey))
+		}
+
+		ctx, span := t.tracer.Start(req.Context(), spanName(req.Method, route),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attributes...),
+		)
+		defer span.End()
+
+		// cloning so injected headers don't leak into the caller's request
+		req = req.Clone(ctx)
+		t.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+		res, err := next.Do(req)
+		endSpan(span, res, err)
+		return res, err
+	})
+}
+
+// requestAttributes is a helper to describe an outbound request
+func requestAttributes(req *http.Request, route string) []attribute.KeyValue {
+	attributes := []attribute.KeyValue{
+		semconv.HTTPMethodKey.String(req.Method),
+		semconv.HTTPURLKey.String(req.URL.String()),
+	}
+	if route != "" {
+		attributes = append(attributes, semconv.HTTPRouteKey.String(route))
+	}
+	return attributes
+}
+
+// endSpan is a helper to record the outcome of a call or attempt
+func endSpan(span trace.Span, res *http.Response, err error) {
+	if res != nil {
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(res.StatusCode))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,62 @@
+// Package tracing traces calls across services with OpenTelemetry,
+// propagating context through W3C traceparent header.
+// it provides middlewares for httpclient, outbound, and for gin, inbound
+package tracing
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies spans created by this package
+const instrumentationName = "playground/common/tracing"
+
+// span attributes specific to httpclient
+const (
+	AttributeAttempt    = "httpclient.attempt"     // attempt number of a call, starting from 1
+	AttributeRetryCount = "httpclient.retry_count" // number of retries of a call
+	AttributeCircuitKey = "httpclient.circuit_key" // circuit breaker command key
+	AttributeFallback   = "httpclient.fallback"    // whether the outcome of a call comes from fallback
+)
+
+// Config is the Tracer configuration
+type Config struct {
+	TracerProvider trace.TracerProvider          // default otel's global provider
+	Propagator     propagation.TextMapPropagator // default W3C trace context, i.e. traceparent and tracestate headers
+}
+
+// Tracer creates spans and propagates their context
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// NewTracer initiates Tracer
+func NewTracer(config Config) *Tracer {
+	// set default value if not defined
+	if config.TracerProvider == nil {
+		config.TracerProvider = otel.GetTracerProvider()
+	}
+
+	// set default value if not defined
+	if config.Propagator == nil {
+		config.Propagator = propagation.TraceContext{}
+	}
+
+	return &Tracer{
+		tracer:     config.TracerProvider.Tracer(instrumentationName),
+		propagator: config.Propagator,
+	}
+}
+
+// spanName is a helper to name span after http method and low-cardinality route,
+// e.g. "HTTP GET /users/{id}"
+func spanName(method, route string) string {
+	if route == "" {
+		return fmt.Sprintf("HTTP %s", method)
+	}
+	return fmt.Sprintf("HTTP %s %s", method, route)
+}
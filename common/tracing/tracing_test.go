@@ -0,0 +1,224 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	httpclient "playground/common/httpclient"
+
+	"github.com/afex/hystrix-go/hystrix"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// commandSeq makes command names unique across repeated test runs
+var commandSeq int64
+
+// this is just a helper, hystrix keeps circuits in globals,
+// so every test gets circuit of its own and flushes it afterwards
+func createCommandName(t *testing.T) string {
+	t.Cleanup(hystrix.Flush)
+	return fmt.Sprintf("%s-%d", t.Name(), atomic.AddInt64(&commandSeq, 1))
+}
+
+// this is just a helper
+func createTestTracer() (*Tracer, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	return NewTracer(Config{TracerProvider: provider}), exporter
+}
+
+// this is just a helper
+func createTestClient(tracer *Tracer, config httpclient.Config) httpclient.HttpClient {
+	config.CallMiddlewares = []httpclient.Middleware{tracer.CallMiddleware}
+	config.AttemptMiddlewares = []httpclient.Middleware{tracer.AttemptMiddleware}
+	return httpclient.NewHttpClient(config)
+}
+
+// this is just a helper
+func attributeOf(span tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, attr := range span.Attributes {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+// this is just a helper
+func spansOfKind(spans tracetest.SpanStubs, kind trace.SpanKind) tracetest.SpanStubs {
+	filtered := tracetest.SpanStubs{}
+	for _, span := range spans {
+		if span.SpanKind == kind {
+			filtered = append(filtered, span)
+		}
+	}
+	return filtered
+}
+
+func Test_HttpClient_SpanPerCallAndAttempt(t *testing.T) {
+	tracer, exporter := createTestTracer()
+
+	var hits int32
+	traceparents := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents <- r.Header.Get("traceparent")
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	commandName := createCommandName(t)
+	client := createTestClient(tracer, httpclient.Config{
+		Host:                  server.URL,
+		RetryCount:            1,
+		RetryPolicy:           httpclient.ConstantRetryPolicy{Delay: time.Millisecond},
+		IsUsingCircuitBreaker: true,
+		CommandNamer:          httpclient.CommandNamerByName(commandName),
+	})
+
+	response, err := client.Get(httpclient.Parameter{
+		Path:       "/users/{id}",
+		PathParams: map[string]string{"id": "1"},
+	})
+	require.NoError(t, err)
+	response.Body.Close()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+
+	calls := spansOfKind(spans, trace.SpanKindInternal)
+	require.Len(t, calls, 1)
+	call := calls[0]
+	assert.Equal(t, "HTTP GET /users/{id}", call.Name)
+	retryCount, _ := attributeOf(call, AttributeRetryCount)
+	assert.Equal(t, int64(1), retryCount.AsInt64())
+	circuitKey, _ := attributeOf(call, AttributeCircuitKey)
+	assert.Equal(t, commandName, circuitKey.AsString())
+	fallback, _ := attributeOf(call, AttributeFallback)
+	assert.False(t, fallback.AsBool())
+	status, _ := attributeOf(call, "http.status_code")
+	assert.Equal(t, int64(http.StatusOK), status.AsInt64())
+	assert.Equal(t, codes.Unset, call.Status.Code)
+
+	attempts := spansOfKind(spans, trace.SpanKindClient)
+	require.Len(t, attempts, 2)
+	for i, attempt := range attempts {
+		assert.Equal(t, call.SpanContext.SpanID(), attempt.Parent.SpanID())
+		assert.Equal(t, call.SpanContext.TraceID(), attempt.SpanContext.TraceID())
+
+		number, _ := attributeOf(attempt, AttributeAttempt)
+		assert.Equal(t, int64(i+1), number.AsInt64())
+
+		// downstream is parented to the attempt
+		traceparent := <-traceparents
+		assert.Contains(t, traceparent, attempt.SpanContext.TraceID().String())
+		assert.Contains(t, traceparent, attempt.SpanContext.SpanID().String())
+	}
+	assert.Equal(t, codes.Error, attempts[0].Status.Code)
+	assert.Equal(t, codes.Unset, attempts[1].Status.Code)
+}
+
+func Test_HttpClient_FallbackSpan(t *testing.T) {
+	tracer, exporter := createTestTracer()
+
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := createTestClient(tracer, httpclient.Config{
+		Host:                  server.URL,
+		IsUsingCircuitBreaker: true,
+		CommandNamer:          httpclient.CommandNamerByName(createCommandName(t)),
+		CbConfig: httpclient.CircuitBreakerConfig{
+			ResponseFallback: httpclient.StaticFallback(http.StatusOK, nil, []byte(`{}`)),
+		},
+	})
+
+	response, err := client.Get(httpclient.Parameter{Path: "/ping"})
+	require.NoError(t, err)
+	response.Body.Close()
+
+	calls := spansOfKind(exporter.GetSpans(), trace.SpanKindInternal)
+	require.Len(t, calls, 1)
+	fallback, _ := attributeOf(calls[0], AttributeFallback)
+	assert.True(t, fallback.AsBool())
+	_, ok := attributeOf(calls[0], AttributeRetryCount)
+	assert.False(t, ok)
+}
+
+func Test_GinMiddleware_ContinuesTrace(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tracer, exporter := createTestTracer()
+
+	r := gin.New()
+	r.Use(tracer.GinMiddleware())
+	r.GET("/users/:id", func(c *gin.Context) {
+		assert.True(t, trace.SpanContextFromContext(c.Request.Context()).IsValid())
+		c.Status(http.StatusNoContent)
+	})
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// alpha calling zulu, both traced
+	client := createTestClient(tracer, httpclient.Config{Host: server.URL})
+	response, err := client.Get(httpclient.Parameter{
+		Path:       "/users/{id}",
+		PathParams: map[string]string{"id": "1"},
+	})
+	require.NoError(t, err)
+	response.Body.Close()
+
+	require.Eventually(t, func() bool {
+		return len(exporter.GetSpans()) == 3
+	}, time.Second, 10*time.Millisecond)
+	spans := exporter.GetSpans()
+
+	servers := spansOfKind(spans, trace.SpanKindServer)
+	attempts := spansOfKind(spans, trace.SpanKindClient)
+	require.Len(t, servers, 1)
+	require.Len(t, attempts, 1)
+
+	assert.Equal(t, "HTTP GET /users/:id", servers[0].Name)
+	assert.Equal(t, attempts[0].SpanContext.TraceID(), servers[0].SpanContext.TraceID())
+	assert.Equal(t, attempts[0].SpanContext.SpanID(), servers[0].Parent.SpanID())
+	assert.True(t, servers[0].Parent.IsRemote())
+
+	status, _ := attributeOf(servers[0], "http.status_code")
+	assert.Equal(t, int64(http.StatusNoContent), status.AsInt64())
+}
+
+func Test_GinMiddleware_StartsTraceWithoutParent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tracer, exporter := createTestTracer()
+
+	r := gin.New()
+	r.Use(tracer.GinMiddleware())
+	r.GET("/fail", func(c *gin.Context) {
+		c.Status(http.StatusInternalServerError)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.False(t, spans[0].Parent.IsValid())
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+}
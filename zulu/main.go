@@ -1,7 +1,10 @@
 package main
 
 import (
+	"playground/common/tracing"
+
 	"github.com/gin-gonic/gin"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 type Response struct {
@@ -11,6 +14,11 @@ type Response struct {
 func main() {
 	r := gin.Default()
 
+	// tracing, continuing traces coming from alpha.
+	// spans are dropped until an exporter is plugged, e.g. sdktrace.WithBatcher(exporter)
+	tracer := tracing.NewTracer(tracing.Config{TracerProvider: sdktrace.NewTracerProvider()})
+	r.Use(tracer.GinMiddleware())
+
 	r.GET("/ping", func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"message": "zulu-pong",